@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/app"
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/credentials"
+	"github.com/SpanishInquisition49/JiraTUI/internal/git"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// The name of the git hook, and of the command run by it
+const commitHook = "prepare-commit-msg"
+
+// The most issues offered by the picker of the hook
+const commitPickerLimit = 50
+
+/**
+ * runPrepareCommitMsg puts the key of the issue being worked on at the start of
+ * the commit message. The key comes from the branch name, or is picked among
+ * the issues in progress of the user. Run by git with the path of the message,
+ * the source of the message and the commit being amended. The commit is never
+ * blocked: the problems are printed as warnings.
+ */
+func runPrepareCommitMsg(args []string) error {
+	// A wrong flag in the hook must not fail the commit
+	fs := flag.NewFlagSet(commitHook, flag.ContinueOnError)
+	configPath, profile := commonFlags(fs)
+	install := fs.Bool("install", false, "install the command as the "+commitHook+" hook of the repository")
+	force := fs.Bool("force", false, "replace the existing hook when installing")
+	if err := parseFlags(fs, args); err != nil {
+		if *install {
+			return err
+		}
+		warn("%v", err)
+		return nil
+	}
+	if *install {
+		// The hook uses the same config and profile
+		passed := []string{}
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "config" || f.Name == "profile" {
+				passed = append(passed, "--"+f.Name+"="+f.Value.String())
+			}
+		})
+		return installHook(*force, passed)
+	}
+	if fs.NArg() == 0 {
+		return errors.New("missing the path of the commit message, or --install")
+	}
+
+	path, source := fs.Arg(0), fs.Arg(1)
+	// Merges, squashes and amended commits already have their message
+	if source == "merge" || source == "squash" || source == "commit" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		warn("%v", err)
+		return nil
+	}
+	if git.MessageHasKey(string(content)) {
+		return nil
+	}
+
+	key := commitKey(*configPath, *profile)
+	if key == "" {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(key+" "+string(content)), 0o644); err != nil {
+		warn("%v", err)
+	}
+	return nil
+}
+
+/**
+ * commitKey finds the key of the commit: the key of the branch if the issue
+ * exists, otherwise the one picked by the user
+ * @param configPath string - The path of the config file
+ * @param name string - The name of the profile, the default one if empty
+ * @return string - The key, empty if there is none
+ */
+func commitKey(configPath string, name string) string {
+	key := ""
+	if branch, err := git.CurrentBranch(); err == nil {
+		key = git.DetectKey(branch)
+	}
+
+	client, cfg, p, err := connectUnattended(configPath, name)
+	if err != nil {
+		warn("%v", err)
+		return key
+	}
+	if key != "" {
+		_, err := client.IssueUpdated(key)
+		switch {
+		case err == nil:
+			return key
+		case errors.Is(err, jira.ErrOffline):
+			// Trust the branch, it cannot be checked
+			warn("%s was not checked: %v", key, err)
+			return key
+		}
+		warn("the branch names %s but it was not found: %v", key, err)
+	}
+
+	query := p.CommitJQL
+	if query == "" {
+		query = config.DefaultCommitJQL
+	}
+	issues, err := client.SearchAll(query, commitPickerLimit)
+	if err != nil {
+		warn("searching %q: %v", query, err)
+		return ""
+	}
+	if len(issues) == 0 {
+		return ""
+	}
+	issue, err := pickIssue(issues, app.ThemeStyles(cfg))
+	if err != nil {
+		warn("%v", err)
+		return ""
+	}
+	if issue == nil {
+		return ""
+	}
+	return issue.Key
+}
+
+/**
+ * connectUnattended connects with a profile without asking anything: the
+ * passphrase of the credentials file comes from the environment and the OAuth
+ * profiles without a token fail instead of opening the browser
+ * @param configPath string - The path of the config file
+ * @param name string - The name of the profile, the default one if empty
+ * @return *jira.Client - The client
+ * @return *config.Config - The loaded configuration
+ * @return config.Profile - The profile
+ * @return error - The error encountered while connecting, if any
+ */
+func connectUnattended(configPath string, name string) (*jira.Client, *config.Config, config.Profile, error) {
+	cfg, name, p, err := loadProfile(configPath, name)
+	if err != nil {
+		return nil, cfg, p, err
+	}
+	auth, err := p.Authenticator(name, credentials.NewDefault(credentials.EnvPassphrase))
+	if oauth, ok := auth.(jira.OAuth); ok {
+		oauth.NoAuthorize = true
+		auth = oauth
+	}
+	var client *jira.Client
+	if err == nil {
+		client, err = jira.NewClient(p.URL, auth)
+	}
+	if err != nil {
+		return nil, cfg, p, fmt.Errorf("connecting with profile %q: %w", name, err)
+	}
+	return client, cfg, p, nil
+}
+
+/**
+ * pickIssue asks for the issue of the commit on the terminal, git gives the hooks
+ * no standard input so the terminal is opened directly
+ * @param issues []jira.Issue - The issues to choose from
+ * @param styles app.AppStyles - The styles of the theme
+ * @return *jira.Issue - The chosen issue, nil if there is no terminal or the user gave up
+ * @return error - The error encountered while running the picker, if any
+ */
+func pickIssue(issues []jira.Issue, styles app.AppStyles) (*jira.Issue, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		slog.Debug("No terminal to pick the issue of the commit", "error", err)
+		return nil, nil
+	}
+	defer tty.Close()
+	picker := app.NewIssuePicker("Issue of the commit", issues, styles)
+	if _, err := tea.NewProgram(picker, tea.WithInput(tty), tea.WithOutput(tty)).Run(); err != nil {
+		return nil, err
+	}
+	return picker.Chosen(), nil
+}
+
+/**
+ * installHook writes the hook of the repository running this executable
+ * @param force bool - Replace a different hook already installed
+ * @param args []string - The flags passed on to the command by the hook, such as --profile
+ * @return error - An error if a different hook exists or if the hook cannot be written
+ */
+func installHook(force bool, args []string) error {
+	dir, err := git.HooksDir()
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if executable, err = filepath.Abs(executable); err != nil {
+		return err
+	}
+	command := []string{shellQuote(executable), commitHook}
+	for _, arg := range args {
+		command = append(command, shellQuote(arg))
+	}
+	script := fmt.Sprintf("#!/bin/sh\nexec %s \"$@\"\n", strings.Join(command, " "))
+
+	path := filepath.Join(dir, commitHook)
+	if existing, err := os.ReadFile(path); err == nil && string(existing) != script && !force {
+		return fmt.Errorf("%s already exists, use --force to replace it", path)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0o755); err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s\n", path)
+	return nil
+}
+
+// shellQuote quotes an argument of the hook script
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// warn prints a problem of the hook without failing the commit
+func warn(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "jiratui: "+format+"\n", args...)
+}
@@ -1,32 +1,251 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/joho/godotenv"
 
 	"github.com/SpanishInquisition49/JiraTUI/internal/app"
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/credentials"
+	"github.com/SpanishInquisition49/JiraTUI/internal/git"
 	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/logging"
 )
 
+// The subcommands, the TUI is started when none is given
+var commands = map[string]func(args []string) error{
+	"login":              runLogin,
+	"logout":             runLogout,
+	"search":             runSearch,
+	"view":               runView,
+	"comment":            runComment,
+	"prepare-commit-msg": runPrepareCommitMsg,
+}
+
 func main() {
-  fmt.Println("Starting Jira TUI...")
-  godotenv.Load()
-  
-	client := jira.CreateClient(
-		os.Getenv("JIRA_EMAIL"),
-		os.Getenv("JIRA_TOKEN"),
-		os.Getenv("JIRA_URL"),
-	)
-
-  app := app.NewModel(client)
-  p := tea.NewProgram(app, tea.WithAltScreen())
-  if _, err := p.Run(); err != nil {
-    fmt.Fprintf(os.Stderr, "Error: %v", err)
-    os.Exit(1)
-  }
+	// The .env file is only used when there is no config file
+	godotenv.Load()
+
+	run := runTUI
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, ok := commands[args[0]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", args[0])
+			os.Exit(2)
+		}
+		run, args = command, args[1:]
+	}
+
+	err := run(args)
+	// os.Exit skips the deferred calls
+	if logOutput != nil {
+		logOutput.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+/**
+ * commonFlags adds the flags shared by every command to the flag set
+ * @param fs *flag.FlagSet - The flag set of the command
+ * @return *string, *string - The path of the config file and the name of the profile
+ */
+func commonFlags(fs *flag.FlagSet) (*string, *string) {
+	configPath := fs.String("config", config.DefaultPath(), "path of the config file")
+	profile := fs.String("profile", "", "name of the profile to use, the default profile if empty")
+	fs.StringVar(&logFile, "log-file", logging.DefaultPath(), "path of the log file, empty to disable the logs")
+	fs.StringVar(&logLevel, "log-level", logging.DefaultLevel, "lowest level logged: debug, info, warn or error")
+	return configPath, profile
+}
+
+// The logging flags, shared by every command
+var logFile, logLevel string
+
+// The log file opened by parseFlags, closed once the command returns
+var logOutput io.Closer
+
+/**
+ * parseFlags parses the arguments of a command and starts logging to the log file.
+ * The logs never go to the terminal, which belongs to the TUI.
+ * @param fs *flag.FlagSet - The flag set of the command, with the common flags
+ * @param args []string - The arguments of the command
+ * @return error - An error if the flags are invalid, the log level is unknown or the log file cannot be opened
+ */
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	output, err := logging.Setup(logFile, logLevel)
+	if err != nil {
+		return err
+	}
+	logOutput = output
+	return nil
+}
+
+/**
+ * loadProfile loads the config file and looks up the profile
+ * @param configPath string - The path of the config file
+ * @param name string - The name of the profile, the default one if empty
+ * @return *config.Config - The loaded configuration
+ * @return string - The name of the profile
+ * @return config.Profile - The profile
+ * @return error - The error encountered while loading the config, if any
+ */
+func loadProfile(configPath string, name string) (*config.Config, string, config.Profile, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, "", config.Profile{}, err
+	}
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	p, err := cfg.Profile(name)
+	return cfg, name, p, err
+}
+
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("jiratui", flag.ExitOnError)
+	configPath, profile := commonFlags(fs)
+	record := fs.String("record", "", "save the requests and the responses, redacted, to this fixture file")
+	replay := fs.String("replay", "", "answer the requests from a fixture file saved with -record, without connecting")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	fmt.Println("Starting Jira TUI...")
+	cfg, name, p, err := loadProfile(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+
+	if *replay != "" {
+		return runReplay(cfg, *replay)
+	}
+	opts := []jira.Option{}
+	if *record != "" {
+		// Every session starts a new fixture, the profiles switched to are appended
+		if err := os.WriteFile(*record, nil, 0o600); err != nil {
+			return err
+		}
+		opts = append(opts, jira.WithRecording(*record))
+	}
+
+	// The TUI owns the terminal once started, the passphrase of the credentials file is asked now
+	store, err := credentials.Unlocked()
+	if err != nil {
+		return err
+	}
+	// Check the connection before starting, the wizard can fix basic auth profiles
+	client, err := p.Connect(name, store, opts...)
+	if err == nil {
+		_, err = client.CheckConnection()
+		// Start anyway, the cached issues are shown until the network is back
+		if errors.Is(err, jira.ErrOffline) {
+			slog.Warn("Starting offline", "error", err)
+			err = nil
+		}
+	}
+	if err != nil {
+		if p.Auth.Method != config.AuthBasic {
+			return err
+		}
+		client, err = runWizard(cfg, *configPath, name, store, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	model := app.NewModel(cfg, name, client, opts...)
+	model.SetCredentials(store)
+	// Start on the issue of the branch being worked on
+	if branch, err := git.CurrentBranch(); err == nil {
+		if key := git.DetectKey(branch); key != "" {
+			model.Preselect(key)
+		}
+	} else {
+		slog.Debug("No git branch to detect the issue from", "error", err)
+	}
+	return runApp(model)
+}
+
+// The profile of the replayed sessions, so that their issues do not end up in the cache of a real profile
+const replayProfile = "replay"
+
+/**
+ * runReplay runs the TUI against a recorded session, the recorded queries
+ * are the default query and the saved queries of a temporary profile
+ * @param cfg *config.Config - The loaded configuration
+ * @param path string - The path of the fixture file
+ * @return error - The error encountered while reading the fixture or running the TUI
+ */
+func runReplay(cfg *config.Config, path string) error {
+	interactions, err := jira.LoadInteractions(path)
+	if err != nil {
+		return err
+	}
+	client, err := jira.NewReplayClient(interactions)
+	if err != nil {
+		return err
+	}
 
+	p := config.Profile{}
+	for _, query := range jira.SearchQueries(interactions) {
+		if p.DefaultJQL == "" {
+			p.DefaultJQL = query
+		}
+		p.SavedQueries = append(p.SavedQueries, config.SavedQuery{Name: query, JQL: query})
+	}
+	// The replay stays offline, the real profiles cannot be picked
+	cfg.Profiles = map[string]config.Profile{replayProfile: p}
+	cfg.DefaultProfile = replayProfile
+	// Start from the recorded queries, not from the tabs of the previous replay
+	os.Remove(app.TabsPath(replayProfile))
+	return runApp(app.NewModel(cfg, replayProfile, client))
+}
+
+// runApp runs the TUI until the user quits, then releases the resources of the model
+func runApp(model tea.Model) error {
+	final, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if closer, ok := final.(io.Closer); ok {
+		closer.Close()
+	}
+	return err
+}
 
+/**
+ * runWizard asks for the connection details until they work
+ * @param cfg *config.Config - The configuration to save the profile to
+ * @param configPath string - The path of the config file
+ * @param name string - The name of the profile
+ * @param store credentials.Store - The store of the token
+ * @param problem error - Why the current profile does not work
+ * @return *jira.Client - The connected client
+ * @return error - An error if the user quit the wizard
+ */
+func runWizard(cfg *config.Config, configPath string, name string, store credentials.Store, problem error) (*jira.Client, error) {
+	// A missing URL means that this is the first run, not a failure
+	if p, _ := cfg.Profile(name); p.URL == "" {
+		problem = nil
+	}
+	wizard := app.NewWizard(cfg, configPath, name, store, problem)
+	if _, err := tea.NewProgram(wizard, tea.WithAltScreen()).Run(); err != nil {
+		return nil, err
+	}
+	if wizard.Client() == nil {
+		return nil, errors.New("setup cancelled")
+	}
+	fmt.Printf("Connected as %s, the profile was saved to %s\n", wizard.User(), configPath)
+	return wizard.Client(), nil
 }
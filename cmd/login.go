@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/credentials"
+)
+
+// runLogin saves the secret of the profile in the credential store
+func runLogin(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	configPath, profile := commonFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	_, name, p, err := loadProfile(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	store := credentials.Default()
+
+	switch p.Auth.Method {
+	case config.AuthOAuth:
+		// Forget the old token so that the authorization is asked again
+		if err := store.Delete(name, credentials.OAuth); err != nil && !errors.Is(err, credentials.ErrNotFound) {
+			return err
+		}
+		auth, err := p.Authenticator(name, store)
+		if err != nil {
+			return err
+		}
+		if _, _, err := auth.Authenticate(p.URL); err != nil {
+			return err
+		}
+	case config.AuthCookie:
+		password, err := credentials.ReadPassphrase(fmt.Sprintf("Password of %s: ", p.Auth.Username))
+		if err != nil {
+			return err
+		}
+		if err := store.Set(name, credentials.Password, password); err != nil {
+			return err
+		}
+	default:
+		token, err := credentials.ReadPassphrase(fmt.Sprintf("Token for %s: ", p.URL))
+		if err != nil {
+			return err
+		}
+		if err := store.Set(name, credentials.Token, token); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Logged in to %s with profile %q\n", p.URL, name)
+	return nil
+}
+
+// runLogout removes every secret of the profile from the credential store
+func runLogout(args []string) error {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	configPath, profile := commonFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	_, name, _, err := loadProfile(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	store := credentials.Default()
+	// Every secret is deleted even if one of them fails
+	var errs []error
+	for _, secret := range []string{credentials.Token, credentials.Password, credentials.OAuth} {
+		if err := store.Delete(name, secret); err != nil && !errors.Is(err, credentials.ErrNotFound) {
+			errs = append(errs, fmt.Errorf("deleting the %s: %w", secret, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	fmt.Printf("Logged out of profile %q\n", name)
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/credentials"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jql"
+	"github.com/SpanishInquisition49/JiraTUI/internal/output"
+)
+
+// runSearch prints the issues matching a query, for scripts
+func runSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	configPath, profile := commonFlags(fs)
+	query := fs.String("jql", "", "the JQL query, the default query of the profile if empty")
+	fields := fs.String("fields", strings.Join(output.DefaultFields, ","), "comma separated fields to print: "+strings.Join(output.Fields, ", "))
+	format := fs.String("output", output.Table, "output format: "+strings.Join(output.Formats, ", "))
+	tmpl := templateFlag(fs)
+	limit := fs.Int("limit", 50, "maximum number of issues, fetched in as many pages as needed, 0 for all of them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	template, err := parseTemplate(*tmpl)
+	if err != nil {
+		return err
+	}
+	columns, err := output.ParseFields(*fields)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(output.Formats, *format) {
+		return fmt.Errorf("unknown output %q, use %s", *format, strings.Join(output.Formats, ", "))
+	}
+	if *limit < 0 {
+		return errors.New("the limit cannot be negative")
+	}
+	client, _, p, err := connect(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	if *query == "" {
+		*query = p.DefaultJQL
+	}
+	// Report the syntax errors without a request, with their position
+	if err := jql.Validate(*query); err != nil {
+		return fmt.Errorf("invalid JQL: %w", err)
+	}
+
+	issues, err := client.SearchAll(*query, *limit)
+	if err != nil {
+		return fmt.Errorf("searching %q: %w", *query, err)
+	}
+	if template != nil {
+		return output.WriteTemplate(os.Stdout, template, issues)
+	}
+	return output.Write(os.Stdout, *format, issues, columns)
+}
+
+// templateFlag adds the --template option of the commands printing issues
+func templateFlag(fs *flag.FlagSet) *string {
+	return fs.String("template", "", "Go template printed for each issue, such as '{{.Key}} {{.Summary | truncate 40}}', or @ and the path of a template file. Replaces --output")
+}
+
+// parseTemplate parses the value of --template, nil if it is not set
+func parseTemplate(spec string) (*template.Template, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	tmpl, err := output.ParseTemplate(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+/**
+ * connect creates the client of a profile for the non-interactive commands,
+ * without the wizard since there may be nobody to answer it
+ * @param configPath string - The path of the config file
+ * @param name string - The name of the profile, the default one if empty
+ * @return *jira.Client - The client of the profile
+ * @return *config.Config - The loaded configuration
+ * @return config.Profile - The profile
+ * @return error - The error encountered while loading the profile or connecting
+ */
+func connect(configPath string, name string) (*jira.Client, *config.Config, config.Profile, error) {
+	cfg, name, p, err := loadProfile(configPath, name)
+	if err != nil {
+		return nil, cfg, p, err
+	}
+	client, err := p.Connect(name, credentials.Default())
+	if err != nil {
+		return nil, cfg, p, fmt.Errorf("connecting with profile %q: %w", name, err)
+	}
+	return client, cfg, p, nil
+}
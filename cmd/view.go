@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/app"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/output"
+)
+
+// The output formats of view
+const (
+	viewMarkdown = "markdown"
+	viewJSON     = "json"
+)
+
+// The width of the Markdown rendered for a terminal when its size is unknown
+const defaultRenderWidth = 80
+
+// runView prints an issue with its comments, as Markdown or JSON
+func runView(args []string) error {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	configPath, profile := commonFlags(fs)
+	format := fs.String("output", viewMarkdown, "output format: markdown or json")
+	tmpl := templateFlag(fs)
+	raw := fs.Bool("raw", false, "print the Markdown as is, even on a terminal")
+	key, err := parseKeyFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	template, err := parseTemplate(*tmpl)
+	if err != nil {
+		return err
+	}
+	if *format != viewMarkdown && *format != viewJSON {
+		return fmt.Errorf("unknown output %q, use markdown or json", *format)
+	}
+
+	client, cfg, _, err := connect(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	issue, err := client.GetIssue(key)
+	if err != nil {
+		return fmt.Errorf("getting %s: %w", key, err)
+	}
+
+	if template != nil {
+		return output.WriteTemplate(os.Stdout, template, []jira.Issue{issue})
+	}
+	if *format == viewJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(issue)
+	}
+	markdown := app.IssueMarkdown(issue)
+	// Render like the card on a terminal, pipes get the Markdown source
+	if fd := int(os.Stdout.Fd()); !*raw && term.IsTerminal(fd) {
+		width, _, err := term.GetSize(fd)
+		if err != nil || width <= 0 {
+			width = defaultRenderWidth
+		}
+		if rendered, err := app.RenderMarkdown(markdown, width, app.ThemeStyles(cfg).MarkdownStyle); err == nil {
+			markdown = rendered
+		}
+	}
+	_, err = fmt.Print(markdown)
+	return err
+}
+
+// runComment adds a comment to an issue, read from -m, from a file or from the standard input
+func runComment(args []string) error {
+	fs := flag.NewFlagSet("comment", flag.ExitOnError)
+	configPath, profile := commonFlags(fs)
+	message := fs.String("m", "", "the comment")
+	file := fs.String("F", "", "read the comment from a file, - for the standard input")
+	key, err := parseKeyFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	body, err := commentBody(*message, *file)
+	if err != nil {
+		return err
+	}
+	client, _, _, err := connect(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	if err := client.AddComment(key, body); err != nil {
+		return fmt.Errorf("commenting %s: %w", key, err)
+	}
+	fmt.Printf("Commented on %s\n", key)
+	return nil
+}
+
+/**
+ * commentBody reads the comment from the flags, or from the standard input when
+ * it is not a terminal, so that `git log -1 | jiratui comment KEY` works
+ * @param message string - The value of -m
+ * @param file string - The value of -F
+ * @return string - The comment
+ * @return error - An error if both or none of the sources are given, or if the comment is empty
+ */
+func commentBody(message string, file string) (string, error) {
+	var content []byte
+	var err error
+	switch {
+	case message != "" && file != "":
+		return "", errors.New("use either -m or -F, not both")
+	case message != "":
+		content = []byte(message)
+	case file == "-" || (file == "" && !term.IsTerminal(int(os.Stdin.Fd()))):
+		content, err = io.ReadAll(os.Stdin)
+	case file != "":
+		content, err = os.ReadFile(file)
+	default:
+		return "", errors.New("no comment, use -m, -F or pipe it to the standard input")
+	}
+	if err != nil {
+		return "", err
+	}
+	body := strings.TrimSpace(string(content))
+	if body == "" {
+		return "", errors.New("the comment is empty")
+	}
+	return body, nil
+}
+
+/**
+ * parseKeyFlags parses the arguments of a command taking an issue key, the
+ * key may come before or after the flags. A bare "-" and the arguments after
+ * "--" are never flags.
+ * @param fs *flag.FlagSet - The flag set of the command, with the common flags
+ * @param args []string - The arguments of the command
+ * @return string - The issue key, upper case
+ * @return error - An error if there is not exactly one key or the flags are invalid
+ */
+func parseKeyFlags(fs *flag.FlagSet, args []string) (string, error) {
+	keys := []string{}
+	// The flag package stops at the first argument that is not a flag
+	for len(args) > 0 {
+		if args[0] == "-" || !strings.HasPrefix(args[0], "-") {
+			keys, args = append(keys, args[0]), args[1:]
+			continue
+		}
+		if err := fs.Parse(args); err != nil {
+			return "", err
+		}
+		rest := fs.Args()
+		if parsed := len(args) - len(rest); args[parsed-1] == "--" {
+			keys = append(keys, rest...)
+			break
+		}
+		args = rest
+	}
+	if err := parseFlags(fs, nil); err != nil {
+		return "", err
+	}
+	if len(keys) != 1 {
+		return "", fmt.Errorf("%s takes one issue key, got %d", fs.Name(), len(keys))
+	}
+	return strings.ToUpper(keys[0]), nil
+}
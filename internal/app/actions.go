@@ -0,0 +1,361 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/queue"
+)
+
+// How often the queued changes are sent again while Jira is not reachable
+const replayInterval = 30 * time.Second
+
+type (
+	replayTickMsg    struct{}
+	queueReplayedMsg struct {
+		sent int
+		err  error
+	}
+)
+
+func replayTick() tea.Cmd {
+	return tea.Tick(replayInterval, func(time.Time) tea.Msg {
+		return replayTickMsg{}
+	})
+}
+
+/**
+ * enqueue records a change to the selected issue and tries to send it at once,
+ * the change stays in the queue until Jira is reachable
+ * @param kind queue.Kind - The kind of change
+ * @param value string - The comment, the target status or the assignee
+ * @return tea.Cmd - The command sending the queued changes
+ */
+func (m *model) enqueue(kind queue.Kind, value string) tea.Cmd {
+	issue := m.tab().issuesList.GetSelectedIssue()
+	value = strings.TrimSpace(value)
+	if issue == nil || value == "" || m.queue == nil {
+		return nil
+	}
+	if err := m.queue.Add(kind, *issue, value); err != nil {
+		slog.Error("Error saving the queue", "error", err)
+	}
+	m.queuePane.SetEntries(m.queue.Entries())
+	return m.replayQueue()
+}
+
+/**
+ * replayQueue sends the pending changes in the background, unless a replay is already running
+ * @return tea.Cmd - The command sending the changes, nil if there is nothing to send
+ */
+func (m *model) replayQueue() tea.Cmd {
+	if m.replaying || m.queue == nil || m.jiraClient == nil {
+		return nil
+	}
+	if pending, _ := m.queue.Count(); pending == 0 {
+		return nil
+	}
+	m.replaying = true
+	q, client := m.queue, m.jiraClient
+	return func() tea.Msg {
+		sent, err := queue.Replay(q, client)
+		return queueReplayedMsg{sent, err}
+	}
+}
+
+// handleQueueReplayed refreshes the views once the queued changes were sent
+func (m *model) handleQueueReplayed(msg queueReplayedMsg) tea.Cmd {
+	m.replaying = false
+	m.queuePane.SetEntries(m.queue.Entries())
+	if msg.sent > 0 {
+		// Fetch the comments again, some of them may have been sent
+		m.comments.SetLoading("")
+	}
+	if msg.err != nil && !errors.Is(msg.err, jira.ErrOffline) {
+		slog.Warn("Error sending the queued changes", "error", msg.err)
+	}
+	// Show the result of the changes
+	if msg.sent > 0 && m.tab().searchInput.Value() != "" {
+		return searchIssues(m, m.tab())
+	}
+	return nil
+}
+
+/**
+ * queueStatus summarizes the queue for the header
+ * @return string - The number of queued changes, empty if there are none
+ */
+func (m *model) queueStatus() string {
+	if m.queue == nil {
+		return ""
+	}
+	pending, problems := m.queue.Count()
+	parts := []string{}
+	if pending > 0 {
+		parts = append(parts, fmt.Sprintf("%d queued", pending))
+	}
+	if problems > 0 {
+		parts = append(parts, fmt.Sprintf("%d to review", problems))
+	}
+	return strings.Join(parts, ", ")
+}
+
+/**
+ * openPrompt asks for the value of a change to the selected issue
+ * @param action promptAction - The change to make
+ * @return tea.Cmd - The command blinking the cursor
+ */
+func (m *model) openPrompt(action promptAction) tea.Cmd {
+	if action == PromptRefresh {
+		value := ""
+		if t := m.tab(); t.refresh > 0 {
+			value = t.refresh.String()
+		}
+		m.ChangeStatus(StatusPrompt)
+		return m.prompt.Open("Run the query again every (e.g. 5m, empty to stop):", action, value, false)
+	}
+	issue := m.tab().issuesList.GetSelectedIssue()
+	if issue == nil {
+		return nil
+	}
+	m.ChangeStatus(StatusPrompt)
+	switch action {
+	case PromptTransition:
+		return m.prompt.Open(fmt.Sprintf("Move %s to status:", issue.Key), action, "", false)
+	case PromptAssign:
+		return m.prompt.Open(fmt.Sprintf("Assign %s to (name, email or me):", issue.Key), action, "", false)
+	}
+	return nil
+}
+
+// submitPrompt runs the action of the prompt with the typed value
+func (m *model) submitPrompt() tea.Cmd {
+	value := m.prompt.Value()
+	m.prompt.Close()
+	switch m.prompt.Action() {
+	case PromptTransition:
+		m.ChangeStatus(StatusDefault)
+		return m.enqueue(queue.Transition, value)
+	case PromptAssign:
+		m.ChangeStatus(StatusDefault)
+		return m.enqueue(queue.Assign, value)
+	case PromptRefresh:
+		m.ChangeStatus(StatusDefault)
+		return m.setRefresh(value)
+	case PromptBranch:
+		m.ChangeStatus(StatusDefault)
+		return m.checkoutBranch(strings.TrimSpace(value))
+	case PromptAuthorize:
+		// The connection goes on in the background
+		m.ChangeStatus(StatusDefault)
+	case PromptEditEntry:
+		m.ChangeStatus(StatusQueue)
+		if err := m.queue.Edit(m.prompt.EntryID(), strings.TrimSpace(value)); err != nil {
+			slog.Error("Error saving the queue", "error", err)
+		}
+		m.queuePane.SetEntries(m.queue.Entries())
+		return m.replayQueue()
+	}
+	return nil
+}
+
+/**
+ * handleKey runs the action bound to a key in the list, the card and the search input
+ * @param msg tea.KeyMsg - The key pressed by the user
+ * @param wasSearchingHistory bool - True if the search input was searching its history before the key
+ * @return tea.Cmd - The command to run
+ * @return bool - False if no action is bound to the key in the current state
+ */
+func (m *model) handleKey(msg tea.KeyMsg, wasSearchingHistory bool) (tea.Cmd, bool) {
+	k := m.keys
+	t := m.tab()
+	issues := m.state == StatusDefault || m.state == StatusIssueDetail
+	switch {
+	case m.state == StatusSearch && key.Matches(msg, k.Search.Submit),
+		m.state == StatusDefault && key.Matches(msg, k.List.Open),
+		m.state == StatusIssueDetail && key.Matches(msg, k.Detail.Back):
+		return m.handleEnter(), true
+	case m.state == StatusSearch && key.Matches(msg, k.Search.Cancel) && !wasSearchingHistory:
+		m.ChangeStatus(StatusDefault)
+		return nil, true
+	case m.state != StatusSearch && key.Matches(msg, k.Global.Help):
+		m.showHelp = true
+		return nil, true
+	case key.Matches(msg, k.Global.NewTab):
+		m.openTab()
+		return nil, true
+	case m.state == StatusIssueDetail && key.Matches(msg, k.Detail.Comment):
+		if t.issuesList.GetSelectedIssue() == nil {
+			return nil, false
+		}
+		m.ChangeStatus(StatusComment)
+		return t.detailCard.StartComment(), true
+	case m.state == StatusDefault && key.Matches(msg, k.List.Profiles):
+		m.openPicker(PickProfile)
+		return nil, true
+	case m.state == StatusDefault && key.Matches(msg, k.List.SavedQueries):
+		m.openPicker(PickSavedQuery)
+		return nil, true
+	case !issues:
+		return nil, false
+	case key.Matches(msg, k.Issues.Quit):
+		return tea.Quit, true
+	case key.Matches(msg, k.Issues.Search):
+		m.ChangeStatus(StatusSearch)
+		return nil, true
+	case key.Matches(msg, k.Issues.Transition):
+		return m.openPrompt(PromptTransition), true
+	case key.Matches(msg, k.Issues.Assign):
+		return m.openPrompt(PromptAssign), true
+	case key.Matches(msg, k.Issues.Refresh):
+		return m.refreshNow(), true
+	case key.Matches(msg, k.Issues.RefreshInterval):
+		return m.openPrompt(PromptRefresh), true
+	case key.Matches(msg, k.Issues.Queue):
+		m.queuePane.SetEntries(m.queue.Entries())
+		m.ChangeStatus(StatusQueue)
+		return nil, true
+	case key.Matches(msg, k.Issues.Export):
+		return m.openExport(), true
+	case key.Matches(msg, k.Issues.Browser):
+		return m.openInBrowser(), true
+	case key.Matches(msg, k.Issues.Copy):
+		m.openCopyPicker()
+		return nil, true
+	case key.Matches(msg, k.Issues.Branch):
+		return m.openBranchPrompt(), true
+	case key.Matches(msg, k.Issues.CloseTab):
+		m.closeTab()
+		return nil, true
+	case key.Matches(msg, k.Issues.NextTab):
+		m.switchTab(1)
+		return nil, true
+	case key.Matches(msg, k.Issues.PrevTab):
+		m.switchTab(-1)
+		return nil, true
+	case key.Matches(msg, k.Issues.GrowList):
+		m.changeLayout(func(l *paneLayout) { l.growList(layoutStep, m.config.Layout) })
+		return nil, true
+	case key.Matches(msg, k.Issues.ShrinkList):
+		m.changeLayout(func(l *paneLayout) { l.growList(-layoutStep, m.config.Layout) })
+		return nil, true
+	case key.Matches(msg, k.Issues.ToggleList):
+		m.changeLayout(func(l *paneLayout) { l.toggle(paneList) })
+		return nil, true
+	case key.Matches(msg, k.Issues.ToggleCard):
+		m.changeLayout(func(l *paneLayout) { l.toggle(paneCard) })
+		return nil, true
+	case key.Matches(msg, k.Issues.Layout):
+		m.openLayoutPicker()
+		return nil, true
+	}
+	return nil, false
+}
+
+/**
+ * handleModalKey routes the keys while a component that takes every key is open
+ * @param msg tea.KeyMsg - The key pressed by the user
+ * @return tea.Cmd - The command to run
+ */
+func (m *model) handleModalKey(msg tea.KeyMsg) tea.Cmd {
+	switch m.state {
+	case StatusPicker:
+		switch {
+		case key.Matches(msg, m.keys.Picker.Choose):
+			return m.handleEnter()
+		case key.Matches(msg, m.keys.Picker.Cancel):
+			m.picker.Close()
+			m.ChangeStatus(StatusDefault)
+			return nil
+		}
+		return m.picker.Update(msg)
+	case StatusComment:
+		t := m.tab()
+		switch {
+		case key.Matches(msg, m.keys.Comment.Send):
+			comment := t.detailCard.Comment()
+			t.detailCard.StopComment()
+			m.ChangeStatus(StatusIssueDetail)
+			return m.enqueue(queue.Comment, comment)
+		case key.Matches(msg, m.keys.Comment.Cancel):
+			t.detailCard.StopComment()
+			m.ChangeStatus(StatusIssueDetail)
+			return nil
+		}
+		_, _, cmd := t.detailCard.Update(msg)
+		return cmd
+	case StatusPrompt:
+		switch {
+		case m.prompt.IsSubmit(msg):
+			return m.submitPrompt()
+		case key.Matches(msg, m.keys.Prompt.Cancel):
+			m.prompt.Close()
+			if m.prompt.Action() == PromptAuthorize {
+				m.cancelConnection()
+			}
+			if m.prompt.Action() == PromptEditEntry {
+				m.ChangeStatus(StatusQueue)
+			} else {
+				m.ChangeStatus(StatusDefault)
+			}
+			return nil
+		}
+		return m.prompt.Update(msg)
+	case StatusQueue:
+		return m.handleQueueKey(msg)
+	case StatusExport:
+		switch {
+		case key.Matches(msg, m.keys.Export.Start):
+			if !m.exportPane.Running() {
+				return m.startExport()
+			}
+			return nil
+		case key.Matches(msg, m.keys.Export.Cancel):
+			m.closeExport()
+			return nil
+		}
+		return m.exportPane.Update(msg)
+	}
+	return nil
+}
+
+// handleQueueKey edits, retries and discards the entries of the queue pane
+func (m *model) handleQueueKey(msg tea.KeyMsg) tea.Cmd {
+	entry, ok := m.queuePane.Selected()
+	var err error
+	switch {
+	case key.Matches(msg, m.keys.Queue.Close):
+		m.ChangeStatus(StatusDefault)
+		return nil
+	case key.Matches(msg, m.keys.Queue.Edit):
+		if !ok {
+			return nil
+		}
+		m.prompt.SetEntryID(entry.ID)
+		m.ChangeStatus(StatusPrompt)
+		title := fmt.Sprintf("Edit the %s of %s:", entry.Kind, entry.IssueKey)
+		return m.prompt.Open(title, PromptEditEntry, entry.Value, entry.Kind == queue.Comment)
+	case key.Matches(msg, m.keys.Queue.Retry):
+		if ok {
+			err = m.queue.Retry(entry.ID)
+		}
+	case key.Matches(msg, m.keys.Queue.Discard):
+		if ok {
+			err = m.queue.Discard(entry.ID)
+		}
+	default:
+		return m.queuePane.Update(msg)
+	}
+	if err != nil {
+		slog.Error("Error saving the queue", "error", err)
+	}
+	m.queuePane.SetEntries(m.queue.Entries())
+	return m.replayQueue()
+}
@@ -1,17 +1,40 @@
 package app
 
 import (
-	"os"
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/SpanishInquisition49/JiraTUI/internal/cache"
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/credentials"
+	"github.com/SpanishInquisition49/JiraTUI/internal/history"
 	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jql"
+	"github.com/SpanishInquisition49/JiraTUI/internal/keymap"
+	"github.com/SpanishInquisition49/JiraTUI/internal/queue"
 )
 
 type (
 	status    uint8
-	issuesMsg struct{ issues []jira.Issue }
+	issuesMsg struct {
+		tabID    int
+		seq      int // The search of the tab that fetched the issues
+		issues   []jira.Issue
+		err      error
+		offline  bool      // True if the issues come from the cache
+		syncedAt time.Time // When the cached issues were fetched
+		refresh  bool      // True if the query was run again in the background
+	}
+	retryTickMsg struct{}
 )
 
 const (
@@ -19,6 +42,10 @@ const (
 	StatusSearch
 	StatusIssueDetail
 	StatusComment
+	StatusPicker
+	StatusPrompt
+	StatusQueue
+	StatusExport
 )
 
 type Styles struct {
@@ -27,123 +54,572 @@ type Styles struct {
 }
 
 type model struct {
-	state       status
-	width       int
-	height      int
-	style       AppStyles
-	jiraClient  *jira.Client
-	searchInput IssueQuery
-	issuesList  IssueList
-	detailCard  IssueCard
-	isStacked   bool
+	state      status
+	width      int
+	height     int
+	style      AppStyles
+	keys       keymap.KeyMap
+	help       help.Model
+	showHelp   bool // True while the help lists every binding of the state
+	config     *config.Config
+	profile    string
+	secrets    credentials.Store
+	clientOpts []jira.Option // The options of the clients of the other profiles
+	jiraClient *jira.Client
+	cache      *cache.Cache
+	queue      *queue.Queue
+	replaying  bool // True while the queued changes are being sent
+	retry      jira.RetryState
+	history    *history.History
+	tabs       []Tab
+	activeTab  int
+	nextTabID  int
+	picker     Picker
+	prompt     Prompt
+	queuePane  QueuePane
+	exportPane ExportPane
+	export     exportJob
+	preselect  string // The key of the issue to select once the results are loaded
+	connection *connection
+	layout     paneLayout
+	comments   CommentsPane
+}
+
+/**
+ * NewModel creates the application model connected to the given profile
+ * @param cfg *config.Config - The loaded configuration
+ * @param profile string - The name of the profile to use, the default one if empty
+ * @param client *jira.Client - The client connected to the instance of the profile
+ * @param opts ...jira.Option - The options used to connect when switching profile
+ * @return *model - The application model
+ */
+func NewModel(cfg *config.Config, profile string, client *jira.Client, opts ...jira.Option) *model {
+	var s AppStyles = ThemeStyles(cfg)
+
+	// The keys were checked when loading the config
+	keys, err := keymap.New(cfg.Keys)
+	if err != nil {
+		slog.Error("Error loading the keys, using the default ones", "error", err)
+		keys = keymap.Default()
+	}
+
+	h, err := history.Load(history.DefaultPath(), cfg.HistorySize)
+	if err != nil {
+		slog.Error("Error loading the query history", "error", err)
+	}
+
+	p := NewPicker()
+	p.SetStyle(s.FocusedStyle)
+	p.SetTitleStyle(s.ListTitleStyle)
+	p.SetItemStyles(s)
+	pr := NewPrompt()
+	pr.SetStyle(s.FocusedStyle)
+	pr.SetTitleStyle(s.ListTitleStyle)
+	pr.SetKeys(keys.Prompt)
+	qp := NewQueuePane()
+	qp.SetStyle(s.FocusedStyle)
+	qp.SetTitleStyle(s.ListTitleStyle)
+	qp.SetItemStyles(s)
+	qp.SetErrorStyle(s.QueryErrorStyle)
+	ep := NewExportPane()
+	ep.SetStyle(s.FocusedStyle)
+	ep.SetTitleStyle(s.ListTitleStyle)
+	ep.SetErrorStyle(s.QueryErrorStyle)
+	ep.SetKeys(keys.Export)
+	cp := NewCommentsPane()
+	cp.SetStyle(s.DefaultStyle)
+	cp.SetTitleStyle(s.ListTitleStyle)
+	cp.SetValueStyle(s.CardValueStyle)
+	cp.SetMarkdownStyle(s.MarkdownStyle)
+
+	m := &model{
+		state:      StatusDefault,
+		style:      s,
+		keys:       keys,
+		help:       newHelp(s),
+		config:     cfg,
+		secrets:    credentials.NewDefault(credentials.EnvPassphrase),
+		clientOpts: opts,
+		history:    h,
+		picker:     p,
+		prompt:     pr,
+		queuePane:  qp,
+		exportPane: ep,
+		comments:   cp,
+	}
+	m.useProfile(cmp.Or(profile, cfg.DefaultProfile), client)
+	return m
+}
+
+// SetCredentials sets the store of the secrets of the profiles, it must not read the terminal owned by the TUI
+func (m *model) SetCredentials(store credentials.Store) {
+	m.secrets = store
+}
+
+/**
+ * useProfile restores the tabs that were open the last time the profile was used
+ * @param name string - The name of the profile
+ * @param client *jira.Client - The client connected to the instance of the profile
+ */
+func (m *model) useProfile(name string, client *jira.Client) {
+	p, _ := m.config.Profile(name)
+	m.profile = name
+	m.jiraClient = client
+
+	// Every profile has its own cache, the issue keys may clash between instances
+	if m.cache != nil {
+		m.cache.Close()
+		m.cache = nil
+	}
+	c, err := cache.Open(cache.DefaultPath(name))
+	if err != nil {
+		slog.Error("Error opening the cache, offline mode disabled", "error", err)
+	} else {
+		m.cache = c
+	}
+
+	m.queue, err = queue.Load(queue.DefaultPath(name))
+	if err != nil {
+		slog.Error("Error loading the queued changes", "error", err)
+	}
+	m.queuePane.SetEntries(m.queue.Entries())
+
+	m.layout, err = loadLayout(LayoutPath(name), m.config.Layout.Preset)
+	if err != nil {
+		slog.Error("Error loading the layout", "error", err)
+	}
+	m.comments.SetLoading("")
+
+	saved, err := loadTabs(TabsPath(name))
+	if err != nil {
+		slog.Error("Error loading the saved tabs", "error", err)
+	}
+	if len(saved.Queries) == 0 {
+		saved = savedTabs{Queries: []string{p.DefaultJQL}, Refresh: []time.Duration{p.Refresh}}
+	}
+	m.tabs = []Tab{}
+	for i, query := range saved.Queries {
+		t := m.newTab(query)
+		t.refresh = saved.Refresh[i]
+		m.tabs = append(m.tabs, t)
+	}
+	m.activeTab = saved.Active
+	m.ChangeStatus(StatusDefault)
 }
 
-func NewModel(jiraClient *jira.Client) *model {
-	var s AppStyles = DefaultStyles()
+/**
+ * newTab creates a tab with the application styles applied
+ * @param query string - The initial query of the tab
+ * @return Tab - The new tab
+ */
+func (m *model) newTab(query string) Tab {
+	s := m.style
 
 	il := NewIssueList()
-	il.SetStyle(s.FocusedStyle)
+	il.SetStyle(s.DefaultStyle)
 	il.SetTitleStyle(s.ListTitleStyle)
+	il.SetStatusStyle(s.ListStatusStyle)
+	il.SetItemStyles(s)
+	il.SetKeys(m.keys.List)
 	ic := NewIssueCard()
 	ic.SetStyle(s.DefaultStyle)
 	ic.SetTitleStyle(s.CardTitleStyle)
 	ic.SetLabelStyle(s.CardLabelStyle)
 	ic.SetValueStyle(s.CardValueStyle)
+	ic.SetMarkdownStyle(s.MarkdownStyle)
 
-	jql := os.Getenv("JIRA_DEFAULT_JQL")
-	si := NewIssueQuery(jql)
+	si := NewIssueQuery(query)
 	si.SetStyle(s.DefaultStyle)
+	si.SetErrorStyle(s.QueryErrorStyle)
+	si.SetTokenStyle(jql.Field, s.QueryFieldStyle)
+	si.SetTokenStyle(jql.Keyword, s.QueryKeywordStyle)
+	si.SetTokenStyle(jql.Operator, s.QueryOperatorStyle)
+	si.SetTokenStyle(jql.String, s.QueryStringStyle)
+	si.SetTokenStyle(jql.Function, s.QueryFunctionStyle)
+	si.SetTokenStyle(jql.Illegal, s.QueryErrorStyle)
+	si.SetHistory(m.history)
+	si.SetKeys(m.keys.Search)
 
-	return &model{
-		state:       StatusDefault,
-		style:       s,
-		jiraClient:  jiraClient,
+	m.nextTabID++
+	return Tab{
+		id:          m.nextTabID,
 		searchInput: si,
 		issuesList:  il,
 		detailCard:  ic,
 	}
 }
 
+// tab returns the active tab
+func (m *model) tab() *Tab {
+	return &m.tabs[m.activeTab]
+}
+
 func (m model) Init() tea.Cmd {
-	if m.searchInput.Value() != "" {
-		// TODO: add the start spinner command
-		return tea.Batch(
-			searchIssues(&m),
-		)
+	return tea.Batch(m.searchAllTabs(), replayTick(), retryTick())
+}
+
+// retryTick polls the retry state of the client, the requests waiting are shown in the header
+func retryTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return retryTickMsg{}
+	})
+}
+
+/**
+ * retryStatus describes the requests waiting to be retried
+ * @return string - The status for the header, empty if no request is waiting
+ */
+func (m *model) retryStatus() string {
+	if m.retry.Waiting == 0 {
+		return ""
 	}
-	return nil
+	wait := time.Until(m.retry.Until).Round(time.Second)
+	if m.retry.RateLimited() {
+		return fmt.Sprintf("rate limited, retrying in %s", max(wait, 0))
+	}
+	return fmt.Sprintf("retrying in %s", max(wait, 0))
+}
+
+// searchAllTabs runs the query of every tab that has one and starts their refresh timers
+func (m *model) searchAllTabs() tea.Cmd {
+	commands := []tea.Cmd{}
+	for i := range m.tabs {
+		if m.tabs[i].searchInput.Value() != "" {
+			commands = append(commands, searchIssues(m, &m.tabs[i]))
+		}
+		commands = append(commands, m.tabs[i].scheduleRefresh())
+	}
+	return tea.Batch(commands...)
+}
+
+func searchIssues(m *model, t *Tab) tea.Cmd {
+	t.query = t.searchInput.Value()
+	t.searchSeq++
+	t.issuesList.SetStatus("")
+	return tea.Batch(
+		t.issuesList.StartSpinner(),
+		fetchIssues(m, t, false),
+	)
 }
 
-func searchIssues(m *model) tea.Cmd {
-  m.issuesList.StartSpinner()
+/**
+ * fetchIssues runs the last query of the tab, falling back to the cache when offline
+ * @param m *model - The application model
+ * @param t *Tab - The tab running the query
+ * @param refresh bool - True if the query is run again in the background
+ * @return tea.Cmd - The command returning the issuesMsg
+ */
+func fetchIssues(m *model, t *Tab, refresh bool) tea.Cmd {
+	client := m.jiraClient
+	issueCache := m.cache
+	id, seq := t.id, t.searchSeq
+	query := t.query
+	local := strings.HasPrefix(query, localSearchPrefix)
 	return func() tea.Msg {
-		issues := m.jiraClient.SearchIssues(m.searchInput.Value())
-		return issuesMsg{issues}
+		if local {
+			return searchCache(issueCache, id, seq, query)
+		}
+		// The client is nil when the profile could not connect
+		var issues []jira.Issue
+		err := jira.ErrOffline
+		if client != nil {
+			issues, err = client.SearchIssues(query)
+		}
+		if err == nil {
+			if issueCache != nil {
+				if err := issueCache.StoreResults(query, issues); err != nil {
+					slog.Error("Error caching the issues", "jql", query, "error", err)
+				}
+			}
+			return issuesMsg{tabID: id, seq: seq, issues: issues, refresh: refresh}
+		}
+		if !errors.Is(err, jira.ErrOffline) || issueCache == nil {
+			return issuesMsg{tabID: id, seq: seq, err: err, refresh: refresh}
+		}
+		cached, syncedAt, cacheErr := issueCache.Results(query)
+		if cacheErr != nil {
+			return issuesMsg{tabID: id, seq: seq, err: err, refresh: refresh}
+		}
+		return issuesMsg{tabID: id, seq: seq, issues: cached, offline: true, syncedAt: syncedAt, refresh: refresh}
+	}
+}
+
+/**
+ * searchCache looks for the text of a local search in the cached issues
+ * @param issueCache *cache.Cache - The cache of the profile, may be nil
+ * @param id int - The id of the tab running the search
+ * @param seq int - The search of the tab
+ * @param query string - The local search, with the leading '?'
+ * @return tea.Msg - The issuesMsg with the matching issues
+ */
+func searchCache(issueCache *cache.Cache, id int, seq int, query string) tea.Msg {
+	if issueCache == nil {
+		return issuesMsg{tabID: id, seq: seq, err: errors.New("the cache is not available")}
 	}
+	issues, err := issueCache.Search(strings.TrimPrefix(query, localSearchPrefix))
+	return issuesMsg{tabID: id, seq: seq, issues: issues, err: err, offline: true}
 }
 
 func (m *model) handleEnter() tea.Cmd {
 	switch m.state {
+	case StatusPicker:
+		return m.pick()
 	case StatusIssueDetail:
 		m.ChangeStatus(StatusDefault)
 		return nil
 	case StatusDefault:
+		if issue := m.tab().issuesList.GetSelectedIssue(); issue != nil {
+			m.tab().issuesList.MarkViewed(issue.Key)
+		}
 		m.ChangeStatus(StatusIssueDetail)
 	case StatusSearch:
+		// Keep the focus on the input so that the error can be fixed
+		if !m.tab().searchInput.Validate() {
+			return nil
+		}
+		if err := m.tab().searchInput.SaveToHistory(); err != nil {
+			slog.Error("Error saving the query history", "error", err)
+		}
 		m.ChangeStatus(StatusDefault)
-		return searchIssues(m)
+		m.saveTabs()
+		return searchIssues(m, m.tab())
 	default:
 		return nil
 	}
 	return nil
 }
 
+/**
+ * openTab adds an empty tab after the active one and focuses its query
+ */
+func (m *model) openTab() {
+	t := m.newTab("")
+	m.activeTab++
+	m.tabs = append(m.tabs[:m.activeTab], append([]Tab{t}, m.tabs[m.activeTab:]...)...)
+	m.ChangeStatus(StatusSearch)
+	m.saveTabs()
+}
+
+/**
+ * closeTab closes the active tab, the last tab is never closed
+ */
+func (m *model) closeTab() {
+	if len(m.tabs) == 1 {
+		return
+	}
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	if m.activeTab >= len(m.tabs) {
+		m.activeTab = len(m.tabs) - 1
+	}
+	m.ChangeStatus(StatusDefault)
+	m.saveTabs()
+}
+
+/**
+ * switchTab activates the tab at the given offset from the active one,
+ * wrapping around at both ends
+ * @param offset int - The number of tabs to move by
+ */
+func (m *model) switchTab(offset int) {
+	m.activeTab = (m.activeTab + offset + len(m.tabs)) % len(m.tabs)
+	m.ChangeStatus(StatusDefault)
+	m.saveTabs()
+}
+
+/**
+ * openPicker shows the picker with the entries for the given action
+ * @param action pickerAction - The action to run with the chosen entry
+ */
+func (m *model) openPicker(action pickerAction) {
+	labels, values := []string{}, []string{}
+	switch action {
+	case PickProfile:
+		for _, name := range m.config.ProfileNames() {
+			label := name
+			if name == m.profile {
+				label += " (current)"
+			}
+			labels = append(labels, label)
+			values = append(values, name)
+		}
+		m.picker.Open("Profiles", action, labels, values)
+	case PickSavedQuery:
+		p, _ := m.config.Profile(m.profile)
+		for _, query := range p.SavedQueries {
+			labels = append(labels, query.Name)
+			values = append(values, query.JQL)
+		}
+		m.picker.Open("Saved queries", action, labels, values)
+	}
+	m.ChangeStatus(StatusPicker)
+}
+
+// pick runs the action of the picker with the chosen entry
+func (m *model) pick() tea.Cmd {
+	value, ok := m.picker.Selected()
+	m.picker.Close()
+	m.ChangeStatus(StatusDefault)
+	if !ok {
+		return nil
+	}
+	switch m.picker.Action() {
+	case PickProfile:
+		if value != m.profile {
+			return m.connectProfile(value)
+		}
+	case PickSavedQuery:
+		// Saved queries are opened in their own tab
+		m.openTab()
+		t := m.tab()
+		t.searchInput.SetValue(value)
+		p, _ := m.config.Profile(m.profile)
+		for _, query := range p.SavedQueries {
+			if query.JQL == value {
+				t.refresh = query.Refresh
+				break
+			}
+		}
+		m.ChangeStatus(StatusDefault)
+		m.saveTabs()
+		return tea.Batch(searchIssues(m, t), t.scheduleRefresh())
+	case PickCopy:
+		return m.copyText(value)
+	case PickLayout:
+		m.changeLayout(func(l *paneLayout) { l.applyPreset(value) })
+	}
+	return nil
+}
+
+func (m *model) saveTabs() {
+	if err := saveTabs(TabsPath(m.profile), m.tabs, m.activeTab); err != nil {
+		slog.Error("Error saving the tabs", "error", err)
+	}
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	// The errors of the query and the open panes change the room of the others
+	next := updated.(model)
+	resize(&next)
+	// loadComments changes the model, it must run before the model is returned
+	loadCmd := next.loadComments()
+	return next, tea.Batch(cmd, loadCmd)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	commands := []tea.Cmd{}
+	_, isKey := msg.(tea.KeyMsg)
+	t := m.tab()
+	// Esc closes the history search before leaving the input
+	wasSearchingHistory := t.searchInput.IsSearching()
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(keyMsg, m.keys.Global.ForceQuit):
+			return m, tea.Quit
+		case m.showHelp:
+			// Any key closes the help
+			m.showHelp = false
+			return m, nil
+		case m.isModal():
+			// Pickers, prompts and editors take every key while they are open
+			return m, m.handleModalKey(keyMsg)
+		}
+	}
 	// Update the search input
-	cmd = m.searchInput.Update(msg)
+	cmd = t.searchInput.Update(msg)
 	commands = append(commands, cmd)
-	if m.state != StatusIssueDetail {
-		_, cmd = m.issuesList.Update(msg)
+	// Keys typed in the search input must not move the other components,
+	// other messages such as the spinner ticks are for every tab
+	for i := range m.tabs {
+		if isKey && (i != m.activeTab || m.state != StatusDefault) {
+			continue
+		}
+		if !isKey && i == m.activeTab && m.state == StatusIssueDetail {
+			continue
+		}
+		_, cmd = m.tabs[i].issuesList.Update(msg)
+		commands = append(commands, cmd)
+	}
+	t.detailCard.SetIssue(t.issuesList.GetSelectedIssue())
+	if !isKey || m.state == StatusIssueDetail {
+		_, _, cmd = t.detailCard.Update(msg)
 		commands = append(commands, cmd)
 	}
-	m.detailCard.SetIssue(m.issuesList.GetSelectedIssue())
-	_, _, cmd = m.detailCard.Update(msg)
-	commands = append(commands, cmd)
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		resize(&m)
 	case issuesMsg:
-		m.issuesList.SetIssues(msg.issues)
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "q":
-			if m.state != StatusSearch {
-				return m, tea.Quit
+		for i := range m.tabs {
+			// A search run since then replaced the query or the profile
+			if m.tabs[i].id != msg.tabID || m.tabs[i].searchSeq != msg.seq {
+				continue
 			}
-		case "ctrl-c":
-			return m, tea.Quit
-		case "enter":
-      return m, m.handleEnter()
-		case "esc":
-			if m.state == StatusComment {
-				m.ChangeStatus(StatusIssueDetail)
-				return m, nil
+			il := &m.tabs[i].issuesList
+			switch {
+			case msg.refresh && msg.err != nil:
+				// Keep the previous results, the next refresh may work
+				il.SetStatus("refresh failed: " + msg.err.Error())
+			case msg.refresh && !msg.offline:
+				il.Refresh(msg.issues)
+				il.SetStatus("")
+				commands = append(commands, m.replayQueue())
+			case msg.refresh:
+				il.SetStatus("offline, last synced " + msg.syncedAt.Format("2 Jan 15:04"))
+			case msg.err != nil:
+				il.SetIssues(nil)
+				il.SetStatus("error: " + msg.err.Error())
+			case msg.offline && msg.syncedAt.IsZero():
+				il.SetIssues(msg.issues)
+				il.SetStatus("cache")
+			case msg.offline:
+				il.SetIssues(msg.issues)
+				il.SetStatus("offline, last synced " + msg.syncedAt.Format("2 Jan 15:04"))
+			default:
+				il.SetIssues(msg.issues)
+				// Jira answered, the queued changes can be sent
+				commands = append(commands, m.replayQueue())
 			}
-			if m.state == StatusSearch {
-				m.ChangeStatus(StatusDefault)
-				return m, nil
+			if !msg.refresh && msg.err == nil {
+				commands = append(commands, m.applyPreselect(&m.tabs[i]))
 			}
-		case "/":
-			if m.state != StatusSearch {
-				m.ChangeStatus(StatusSearch)
-				return m, nil
+		}
+	case preselectMsg:
+		commands = append(commands, m.handlePreselect(msg))
+	case refreshTickMsg:
+		commands = append(commands, m.handleRefreshTick(msg))
+	case retryTickMsg:
+		m.retry = jira.RetryState{}
+		if m.jiraClient != nil {
+			m.retry = m.jiraClient.RetryState()
+		}
+		commands = append(commands, retryTick())
+	case replayTickMsg:
+		commands = append(commands, m.replayQueue(), replayTick())
+	case queueReplayedMsg:
+		commands = append(commands, m.handleQueueReplayed(msg))
+	case exportPageMsg:
+		commands = append(commands, m.handleExportPage(msg))
+	case exportDoneMsg:
+		m.handleExportDone(msg)
+	case commentsMsg:
+		m.handleComments(msg)
+	case authorizeMsg:
+		commands = append(commands, m.handleAuthorize(msg))
+	case profileConnectedMsg:
+		commands = append(commands, m.handleProfileConnected(msg))
+	case statusMsg:
+		for i := range m.tabs {
+			if m.tabs[i].id == msg.tabID {
+				m.tabs[i].issuesList.SetStatus(msg.status)
 			}
 		}
+	case tea.KeyMsg:
+		if cmd, handled := m.handleKey(msg, wasSearchingHistory); handled {
+			return m, cmd
+		}
 	}
 
 	return m, tea.Batch(commands...)
@@ -154,66 +630,79 @@ func (m model) View() string {
 		return "Initializing..."
 	}
 
+	t := m.tab()
 	var content string
 
-	if m.isStacked {
-		content = lipgloss.JoinVertical(
-			lipgloss.Left,
-			m.issuesList.View(),
-			m.detailCard.View(),
-		)
-	} else {
-		content = lipgloss.JoinHorizontal(
-			lipgloss.Left,
-			m.issuesList.View(),
-			m.detailCard.View(),
-		)
+	switch {
+	case m.showHelp:
+		content = m.helpView()
+	case m.state == StatusPicker:
+		content = m.picker.View()
+	case m.state == StatusQueue:
+		content = m.queuePane.View()
+	case m.state == StatusPrompt:
+		content = m.prompt.View()
+	case m.state == StatusExport:
+		content = m.exportPane.View()
+	default:
+		content = m.panesView()
 	}
 
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
-		m.searchInput.View(),
+		m.headerView(),
+		t.searchInput.View(),
 		content,
+		m.helpFooter(),
 	)
 }
 
+// headerView shows the profile, the tabs and the pending requests above the query
+func (m *model) headerView() string {
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(lipgloss.JoinHorizontal(
+		lipgloss.Bottom,
+		m.style.ProfileStyle.Render(m.profile),
+		tabBar(m.tabs, m.activeTab, m.style.TabStyle, m.style.ActiveTabStyle),
+		m.style.QueueStatusStyle.Render(m.queueStatus()),
+		m.style.QueueStatusStyle.Render(m.retryStatus()),
+	))
+}
+
+// isModal reports whether the current state takes every key
+func (m *model) isModal() bool {
+	switch m.state {
+	case StatusPicker, StatusComment, StatusPrompt, StatusQueue, StatusExport:
+		return true
+	}
+	return false
+}
+
 func (m *model) ChangeStatus(newStatus status) {
+	t := m.tab()
 	// Reset
-	m.searchInput.SetStyle(m.style.DefaultStyle)
-	m.issuesList.SetStyle(m.style.DefaultStyle)
-	m.detailCard.SetStyle(m.style.DefaultStyle)
-	m.searchInput.Blur()
+	t.searchInput.SetStyle(m.style.DefaultStyle)
+	t.issuesList.SetStyle(m.style.DefaultStyle)
+	t.detailCard.SetStyle(m.style.DefaultStyle)
+	t.searchInput.Blur()
 	switch newStatus {
 	case StatusSearch:
-		m.searchInput.SetStyle(m.style.FocusedStyle)
-		m.searchInput.Focus()
+		t.searchInput.SetStyle(m.style.FocusedStyle)
+		t.searchInput.Focus()
 	case StatusIssueDetail:
-		m.detailCard.SetStyle(m.style.FocusedStyle)
+		t.detailCard.SetStyle(m.style.FocusedStyle)
 	case StatusDefault:
-		m.issuesList.SetStyle(m.style.FocusedStyle)
+		t.issuesList.SetStyle(m.style.FocusedStyle)
 	}
-  m.state = newStatus
+	m.state = newStatus
 }
 
-func resize(m *model) {
-	// Decide layout: side-by-side or stacked
-	m.isStacked = m.width <= 80
-	// Set responsive dimensions for the input field
-	// m.searchInput.Width = m.width - 7 // Leave padding for borders
-
-	// Adjust the issue list and issue card dynamically
-	//availableHeight := m.height - 4 // Account for input field and padding
-	//listHeight := availableHeight / 2
-	//if availableHeight > 10 {
-	//listHeight = availableHeight * 2 / 3 // Allocate more space to the list
-	//}
-
-	// Allocate proportions for wide layout
-	// listWidth := m.width / 3 // Give one-third of the width to the issue list
-	// cardWidth := m.width - listWidth  // Remaining space for the issue card
-
-	// TODO: change the size of the components
-
-	// m.issuesList.SetHeight(listHeight)
-	// m.issuesList.SetWidth(listWidth)
+/**
+ * Close releases the resources held by the model, to call once the program exits
+ * @return error - The error encountered while closing the cache, if any
+ */
+func (m model) Close() error {
+	if m.cache == nil {
+		return nil
+	}
+	return m.cache.Close()
 }
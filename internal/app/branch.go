@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/git"
+)
+
+// preselectMsg tells whether the issue of the current branch exists, when it is not in the first results
+type preselectMsg struct {
+	key string
+	err error
+}
+
+/**
+ * Preselect selects an issue once the first results are loaded, such as the
+ * issue of the current git branch. The issue is opened in its own tab if it
+ * is not in the results of the active tab.
+ * @param key string - The key of the issue
+ */
+func (m *model) Preselect(key string) {
+	m.preselect = key
+}
+
+/**
+ * applyPreselect selects the preselected issue in the results of a tab
+ * @param t *Tab - The tab that got its first results
+ * @return tea.Cmd - The command checking that the issue exists when it is not in the results
+ */
+func (m *model) applyPreselect(t *Tab) tea.Cmd {
+	key := m.preselect
+	if key == "" || t.id != m.tab().id {
+		return nil
+	}
+	if t.issuesList.Select(key) {
+		m.preselect = ""
+		m.ChangeStatus(StatusIssueDetail)
+		return nil
+	}
+	// The tab opened for the issue did not find it either
+	if t.query == keyQuery(key) || m.jiraClient == nil {
+		m.preselect = ""
+		return nil
+	}
+	client := m.jiraClient
+	return func() tea.Msg {
+		_, err := client.IssueUpdated(key)
+		return preselectMsg{key, err}
+	}
+}
+
+// handlePreselect opens the preselected issue in a new tab, if it exists
+func (m *model) handlePreselect(msg preselectMsg) tea.Cmd {
+	if msg.key != m.preselect {
+		return nil
+	}
+	if msg.err != nil {
+		// The branch name only looked like it had a key
+		m.preselect = ""
+		return nil
+	}
+	// Reuse the tab opened for the issue by a previous session
+	for i := range m.tabs {
+		if m.tabs[i].query == keyQuery(msg.key) {
+			m.switchTab(i - m.activeTab)
+			// Otherwise the issue is selected once the results of the tab are loaded
+			if m.tab().issuesList.Select(msg.key) {
+				m.preselect = ""
+				m.ChangeStatus(StatusIssueDetail)
+			}
+			return nil
+		}
+	}
+	m.openTab()
+	t := m.tab()
+	t.searchInput.SetValue(keyQuery(msg.key))
+	m.ChangeStatus(StatusDefault)
+	m.saveTabs()
+	return searchIssues(m, t)
+}
+
+// keyQuery returns the query finding a single issue
+func keyQuery(key string) string {
+	return "key = " + key
+}
+
+// openBranchPrompt asks for the name of the branch of the selected issue, filled from the template
+func (m *model) openBranchPrompt() tea.Cmd {
+	t := m.tab()
+	issue := t.issuesList.GetSelectedIssue()
+	if issue == nil {
+		return nil
+	}
+	name, err := git.BranchName(m.config.BranchTemplate, *issue)
+	if err != nil {
+		t.issuesList.SetStatus("error: " + err.Error())
+		return nil
+	}
+	m.ChangeStatus(StatusPrompt)
+	return m.prompt.Open(fmt.Sprintf("Create and check out the branch of %s:", issue.Key), PromptBranch, name, false)
+}
+
+/**
+ * checkoutBranch switches to the branch of an issue, creating it if needed
+ * @param name string - The name of the branch
+ * @return tea.Cmd - The command running git
+ */
+func (m *model) checkoutBranch(name string) tea.Cmd {
+	if name == "" {
+		return nil
+	}
+	id := m.tab().id
+	return func() tea.Msg {
+		created, err := git.Checkout(name)
+		switch {
+		case err != nil:
+			return statusMsg{id, "error: " + err.Error()}
+		case created:
+			return statusMsg{id, "created the branch " + name}
+		}
+		return statusMsg{id, "switched to the branch " + name}
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// commentsMsg holds the comments of an issue fetched for the comments pane
+type commentsMsg struct {
+	key      string
+	comments []jira.Comment
+	err      error
+}
+
+/**
+ * loadComments shows the comments of the selected issue in the comments pane,
+ * fetching them when the search did not return them
+ * @return tea.Cmd - The command fetching the comments, nil if the pane is hidden or already shows them
+ */
+func (m *model) loadComments() tea.Cmd {
+	if !slices.Contains(m.visiblePanes(), paneComments) {
+		return nil
+	}
+	issue := m.tab().issuesList.GetSelectedIssue()
+	switch {
+	case issue == nil:
+		m.comments.SetLoading("")
+		return nil
+	case issue.Key == m.comments.Key():
+		return nil
+	case issue.Comments != nil:
+		m.comments.SetComments(issue.Key, issue.Comments, nil)
+		return nil
+	case m.jiraClient == nil:
+		m.comments.SetComments(issue.Key, nil, jira.ErrOffline)
+		return nil
+	}
+	key, client := issue.Key, m.jiraClient
+	m.comments.SetLoading(key)
+	return func() tea.Msg {
+		issue, err := client.GetIssue(key)
+		return commentsMsg{key, issue.Comments, err}
+	}
+}
+
+// handleComments shows the fetched comments, unless another issue was selected meanwhile
+func (m *model) handleComments(msg commentsMsg) {
+	if msg.key != m.comments.Key() {
+		return
+	}
+	m.comments.SetComments(msg.key, msg.comments, msg.err)
+}
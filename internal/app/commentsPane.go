@@ -0,0 +1,139 @@
+package app
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/theme"
+)
+
+// CommentsPane shows the comments of the selected issue next to its card
+type CommentsPane struct {
+	style         lipgloss.Style
+	titleStyle    lipgloss.Style
+	valueStyle    lipgloss.Style
+	markdownStyle string
+	width         int // The size inside the border and the padding
+	height        int
+	key           string // The issue of the comments, empty if none is selected
+	comments      []jira.Comment
+	loading       bool
+	err           error
+	content       string // The rendered comments, rendered again when they or the width change
+	stale         bool
+}
+
+func NewCommentsPane() CommentsPane {
+	return CommentsPane{
+		style:         lipgloss.NewStyle(),
+		titleStyle:    lipgloss.NewStyle(),
+		valueStyle:    lipgloss.NewStyle(),
+		markdownStyle: theme.Default().Glamour,
+	}
+}
+
+func (cp *CommentsPane) SetStyle(style lipgloss.Style) {
+	cp.style = style
+}
+
+func (cp *CommentsPane) SetTitleStyle(style lipgloss.Style) {
+	cp.titleStyle = style
+}
+
+// SetValueStyle sets the style of the messages shown in place of the comments
+func (cp *CommentsPane) SetValueStyle(style lipgloss.Style) {
+	cp.valueStyle = style
+}
+
+// SetMarkdownStyle sets the glamour style of the comments, a standard name or the path of a JSON style
+func (cp *CommentsPane) SetMarkdownStyle(style string) {
+	cp.markdownStyle = style
+	cp.stale = true
+}
+
+/**
+ * SetSize sets the size of the pane, the border and the padding of its style included
+ * @param width int - The width of the pane
+ * @param height int - The height of the pane
+ */
+func (cp *CommentsPane) SetSize(width int, height int) {
+	inner := max(width-cp.style.GetHorizontalFrameSize(), 0)
+	if inner != cp.width {
+		cp.stale = true
+	}
+	cp.width = inner
+	cp.height = max(height-cp.style.GetVerticalFrameSize(), 0)
+}
+
+// Key returns the issue whose comments are shown or loading, empty if none
+func (cp *CommentsPane) Key() string {
+	return cp.key
+}
+
+/**
+ * SetLoading shows that the comments of an issue are being fetched
+ * @param key string - The key of the issue, empty if no issue is selected
+ */
+func (cp *CommentsPane) SetLoading(key string) {
+	cp.key = key
+	cp.comments = nil
+	cp.loading = key != ""
+	cp.err = nil
+	cp.stale = true
+}
+
+/**
+ * SetComments shows the comments of an issue
+ * @param key string - The key of the issue
+ * @param comments []jira.Comment - The comments, oldest first
+ * @param err error - The error encountered while fetching them, if any
+ */
+func (cp *CommentsPane) SetComments(key string, comments []jira.Comment, err error) {
+	cp.key = key
+	cp.comments = comments
+	cp.loading = false
+	cp.err = err
+	cp.stale = true
+}
+
+func (cp *CommentsPane) View() string {
+	if cp.stale {
+		cp.content = cp.render()
+		cp.stale = false
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, cp.titleStyle.Render("Comments"), "", cp.content)
+	content = lipgloss.NewStyle().MaxHeight(cp.height).Render(content)
+	return cp.style.Width(cp.width + cp.style.GetHorizontalPadding()).
+		Height(cp.height + cp.style.GetVerticalPadding()).
+		Render(content)
+}
+
+// render formats the comments, the newest first so that the pane cuts the oldest ones
+func (cp *CommentsPane) render() string {
+	message := ""
+	switch {
+	case cp.key == "":
+		message = "No issue selected"
+	case cp.loading:
+		message = "Loading..."
+	case errors.Is(cp.err, jira.ErrOffline):
+		message = "Not available offline"
+	case cp.err != nil:
+		message = "error: " + cp.err.Error()
+	case len(cp.comments) == 0:
+		message = "No comments"
+	}
+	if message != "" {
+		return cp.valueStyle.Width(cp.width).Render(message)
+	}
+	parts := []string{}
+	for i := len(cp.comments) - 1; i >= 0; i-- {
+		parts = append(parts, commentMarkdown(cp.comments[i]))
+	}
+	// glamour adds its margins around the wrapped lines
+	rendered, _ := RenderMarkdown(strings.Join(parts, "\n"), max(cp.width-glamourMargins, 1), cp.markdownStyle)
+	return strings.Trim(rendered, "\n")
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/browser"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// connection is a profile being connected to in the background
+type connection struct {
+	name   string
+	events chan tea.Msg // The authorization URL, then the result of the connection
+	cancel context.CancelFunc
+}
+
+type (
+	// authorizeMsg asks the user to authorize the application for an OAuth profile
+	authorizeMsg struct {
+		conn *connection
+		url  string
+	}
+	// profileConnectedMsg ends the connection to a profile
+	profileConnectedMsg struct {
+		conn   *connection
+		client *jira.Client
+		err    error
+	}
+)
+
+/**
+ * connectProfile switches to another profile once connected to its instance.
+ * The connection runs in the background, the OAuth profiles may wait for the
+ * user to authorize the application in the browser.
+ * @param name string - The name of the profile
+ * @return tea.Cmd - The command waiting for the connection
+ */
+func (m *model) connectProfile(name string) tea.Cmd {
+	p, err := m.config.Profile(name)
+	if err != nil {
+		slog.Error("Error loading the profile", "profile", name, "error", err)
+		return nil
+	}
+	// Only the last profile chosen is connected to
+	m.cancelConnection()
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &connection{name: name, events: make(chan tea.Msg, 2), cancel: cancel}
+	m.connection = conn
+	m.tab().issuesList.SetStatus("connecting to " + name + "...")
+
+	store, opts := m.secrets, m.clientOpts
+	go func() {
+		auth, err := p.Authenticator(name, store)
+		if oauth, ok := auth.(jira.OAuth); ok {
+			oauth.Context = ctx
+			oauth.Prompt = func(url string) {
+				conn.events <- authorizeMsg{conn, url}
+			}
+			auth = oauth
+		}
+		var client *jira.Client
+		if err == nil {
+			client, err = jira.NewClient(p.URL, auth, opts...)
+		}
+		conn.events <- profileConnectedMsg{conn, client, err}
+	}()
+	return conn.wait()
+}
+
+// wait returns the next event of the connection
+func (c *connection) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-c.events
+	}
+}
+
+// cancelConnection stops the connection in progress, if any
+func (m *model) cancelConnection() {
+	if m.connection != nil {
+		m.connection.cancel()
+	}
+}
+
+/**
+ * handleAuthorize shows the authorization URL and opens it in the browser
+ * @param msg authorizeMsg - The URL of the authorization
+ * @return tea.Cmd - The command waiting for the rest of the connection
+ */
+func (m *model) handleAuthorize(msg authorizeMsg) tea.Cmd {
+	if msg.conn != m.connection {
+		return msg.conn.wait()
+	}
+	m.ChangeStatus(StatusPrompt)
+	title := "Authorize JiraTUI for " + msg.conn.name + " at this URL, esc cancels:"
+	command := m.config.Browser
+	return tea.Batch(
+		m.prompt.Open(title, PromptAuthorize, msg.url, true),
+		msg.conn.wait(),
+		func() tea.Msg {
+			if err := browser.Open(command, msg.url); err != nil {
+				slog.Warn("Error opening the browser", "error", err)
+			}
+			return nil
+		},
+	)
+}
+
+/**
+ * handleProfileConnected switches to the profile, even without a client the
+ * cached issues are shown. A cancelled connection keeps the current profile.
+ * @param msg profileConnectedMsg - The result of the connection
+ * @return tea.Cmd - The command running the queries of the restored tabs
+ */
+func (m *model) handleProfileConnected(msg profileConnectedMsg) tea.Cmd {
+	msg.conn.cancel()
+	if msg.conn != m.connection {
+		return nil
+	}
+	m.connection = nil
+	if m.state == StatusPrompt && m.prompt.Action() == PromptAuthorize {
+		m.prompt.Close()
+		m.ChangeStatus(StatusDefault)
+	}
+	if errors.Is(msg.err, context.Canceled) {
+		m.tab().issuesList.SetStatus("connection to " + msg.conn.name + " cancelled")
+		return nil
+	}
+	if msg.err != nil {
+		slog.Error("Error creating Jira client", "error", msg.err)
+	}
+	m.useProfile(msg.conn.name, msg.client)
+	return m.searchAllTabs()
+}
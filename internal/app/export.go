@@ -0,0 +1,151 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/clipboard"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/output"
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+type (
+	exportPageMsg struct {
+		seq    int
+		issues []jira.Issue
+		total  int
+		err    error
+	}
+	exportDoneMsg struct {
+		seq     int
+		message string
+		err     error
+	}
+)
+
+// An export in progress, the pages of the query are fetched one after the other
+type exportJob struct {
+	seq     int // Identifies the messages of the job, the older jobs were cancelled
+	query   string
+	options exportOptions
+	issues  []jira.Issue
+}
+
+// openExport shows the export form for the issues of the active tab
+func (m *model) openExport() tea.Cmd {
+	m.ChangeStatus(StatusExport)
+	return m.exportPane.Open()
+}
+
+// closeExport hides the export form, cancelling the export in progress
+func (m *model) closeExport() {
+	m.export.seq++
+	m.ChangeStatus(StatusDefault)
+}
+
+/**
+ * startExport exports the issues of the active tab as chosen in the export form
+ * @return tea.Cmd - The command fetching the first page or writing the loaded issues
+ */
+func (m *model) startExport() tea.Cmd {
+	options := m.exportPane.Options()
+	switch {
+	case len(options.columns) == 0:
+		m.exportPane.Finish("", errors.New("choose at least one column"))
+		return nil
+	case !options.clipboard && options.path == "":
+		m.exportPane.Finish("", errors.New("choose the path of the file"))
+		return nil
+	}
+
+	t := m.tab()
+	m.export = exportJob{seq: m.export.seq + 1, query: t.query, options: options}
+	m.exportPane.Start()
+	// The local searches have no other page than the cached issues
+	if !options.all || t.query == "" || strings.HasPrefix(t.query, localSearchPrefix) {
+		return writeExport(m.export.seq, options, t.issuesList.Issues())
+	}
+	m.exportPane.SetProgress(0, 0)
+	return fetchExportPage(m.jiraClient, m.export.seq, m.export.query, 0)
+}
+
+/**
+ * fetchExportPage fetches a page of the results of the exported query
+ * @param client *jira.Client - The client of the profile, nil when it could not connect
+ * @param seq int - The job of the page
+ * @param query string - The exported query
+ * @param startAt int - The index of the first issue of the page
+ * @return tea.Cmd - The command returning the exportPageMsg
+ */
+func fetchExportPage(client *jira.Client, seq int, query string, startAt int) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			return exportPageMsg{seq: seq, err: jira.ErrOffline}
+		}
+		issues, total, err := client.SearchPage(query, startAt, jira.SearchPageSize)
+		return exportPageMsg{seq: seq, issues: issues, total: total, err: err}
+	}
+}
+
+// handleExportPage shows the progress and fetches the next page, the issues are written after the last one
+func (m *model) handleExportPage(msg exportPageMsg) tea.Cmd {
+	if msg.seq != m.export.seq {
+		return nil
+	}
+	if msg.err != nil {
+		m.exportPane.Finish("", msg.err)
+		return nil
+	}
+	m.export.issues = append(m.export.issues, msg.issues...)
+	loaded := len(m.export.issues)
+	if len(msg.issues) > 0 && loaded < msg.total {
+		m.exportPane.SetProgress(loaded, msg.total)
+		return fetchExportPage(m.jiraClient, msg.seq, m.export.query, loaded)
+	}
+	m.exportPane.SetProgress(loaded, loaded)
+	return writeExport(msg.seq, m.export.options, m.export.issues)
+}
+
+func (m *model) handleExportDone(msg exportDoneMsg) {
+	if msg.seq == m.export.seq {
+		m.exportPane.Finish(msg.message, msg.err)
+		m.export.issues = nil
+	}
+}
+
+/**
+ * writeExport writes the issues to the clipboard or to a file
+ * @param seq int - The job of the export
+ * @param options exportOptions - The format, the columns and the destination
+ * @param issues []jira.Issue - The issues to export
+ * @return tea.Cmd - The command returning the exportDoneMsg
+ */
+func writeExport(seq int, options exportOptions, issues []jira.Issue) tea.Cmd {
+	return func() tea.Msg {
+		var b bytes.Buffer
+		if err := output.Write(&b, options.format, issues, options.columns); err != nil {
+			return exportDoneMsg{seq: seq, err: err}
+		}
+		if options.clipboard {
+			if err := clipboard.Copy(b.String()); err != nil {
+				return exportDoneMsg{seq: seq, err: err}
+			}
+			return exportDoneMsg{seq: seq, message: fmt.Sprintf("Copied %d issues to the clipboard", len(issues))}
+		}
+		path := xdg.ExpandHome(options.path)
+		if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+			return exportDoneMsg{seq: seq, err: err}
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		return exportDoneMsg{seq: seq, message: fmt.Sprintf("Exported %d issues to %s", len(issues), path)}
+	}
+}
@@ -0,0 +1,282 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/keymap"
+	"github.com/SpanishInquisition49/JiraTUI/internal/output"
+)
+
+// The formats offered by the export and the extensions of their files
+var (
+	exportFormats    = []string{output.CSV, output.JSON, output.Markdown}
+	exportExtensions = map[string]string{output.CSV: "csv", output.JSON: "json", output.Markdown: "md"}
+)
+
+// The name of the exported file, before the extension of the format
+const exportFileName = "issues"
+
+// The rows of the export form, the columns follow, one row per field
+const (
+	exportRowFormat = iota
+	exportRowSource
+	exportRowTarget
+	exportRowPath
+	exportRowColumns
+)
+
+// What to export and where, as chosen in the export pane
+type exportOptions struct {
+	format    string
+	all       bool // Page through all the results of the query, not only the loaded issues
+	clipboard bool
+	path      string
+	columns   []string
+}
+
+// ExportPane asks how to export the issues of the tab and shows the progress of the export
+type ExportPane struct {
+	style      lipgloss.Style
+	titleStyle lipgloss.Style
+	errorStyle lipgloss.Style
+	keys       keymap.Export
+	cursor     int
+	format     int // The index in exportFormats
+	all        bool
+	clipboard  bool
+	path       textinput.Model
+	columns    map[string]bool
+	running    bool
+	message    string
+	failed     bool
+}
+
+func NewExportPane() ExportPane {
+	path := textinput.New()
+	path.Prompt = ""
+	path.Width = 40
+	path.SetValue(exportFileName + "." + exportExtensions[exportFormats[0]])
+	columns := map[string]bool{}
+	for _, field := range output.DefaultFields {
+		columns[field] = true
+	}
+	return ExportPane{
+		style:      lipgloss.NewStyle(),
+		titleStyle: lipgloss.NewStyle(),
+		errorStyle: lipgloss.NewStyle(),
+		keys:       keymap.Default().Export,
+		path:       path,
+		columns:    columns,
+	}
+}
+
+func (ep *ExportPane) SetStyle(style lipgloss.Style) {
+	ep.style = style
+}
+
+// SetKeys sets the bindings moving through the form and changing its choices
+func (ep *ExportPane) SetKeys(keys keymap.Export) {
+	ep.keys = keys
+}
+
+func (ep *ExportPane) SetTitleStyle(style lipgloss.Style) {
+	ep.titleStyle = style
+}
+
+func (ep *ExportPane) SetErrorStyle(style lipgloss.Style) {
+	ep.errorStyle = style
+}
+
+/**
+ * Open shows the form with the choices of the previous export
+ * @return tea.Cmd - The command blinking the cursor of the path
+ */
+func (ep *ExportPane) Open() tea.Cmd {
+	ep.running = false
+	ep.message = ""
+	ep.failed = false
+	return ep.focusRow()
+}
+
+/**
+ * Options returns the choices of the form
+ * @return exportOptions - What to export and where
+ */
+func (ep *ExportPane) Options() exportOptions {
+	columns := []string{}
+	for _, field := range output.Fields {
+		if ep.columns[field] {
+			columns = append(columns, field)
+		}
+	}
+	return exportOptions{
+		format:    exportFormats[ep.format],
+		all:       ep.all,
+		clipboard: ep.clipboard,
+		path:      strings.TrimSpace(ep.path.Value()),
+		columns:   columns,
+	}
+}
+
+func (ep *ExportPane) Running() bool {
+	return ep.running
+}
+
+// Start shows that the export is running, until Finish is called
+func (ep *ExportPane) Start() {
+	ep.running = true
+	ep.failed = false
+	ep.message = "Exporting..."
+	ep.path.Blur()
+}
+
+/**
+ * SetProgress shows how many issues were fetched so far
+ * @param loaded int - The number of issues fetched
+ * @param total int - The number of issues matching the query, 0 if not known yet
+ */
+func (ep *ExportPane) SetProgress(loaded int, total int) {
+	if total == 0 {
+		ep.message = fmt.Sprintf("Fetching the issues... %d", loaded)
+		return
+	}
+	ep.message = fmt.Sprintf("Fetching the issues... %d/%d (%d%%)", loaded, total, loaded*100/total)
+}
+
+/**
+ * Finish shows the result of the export
+ * @param message string - What was exported, shown if there is no error
+ * @param err error - The error that stopped the export, if any
+ */
+func (ep *ExportPane) Finish(message string, err error) {
+	ep.running = false
+	ep.message = message
+	ep.failed = err != nil
+	if err != nil {
+		ep.message = "Export failed: " + err.Error()
+	}
+	ep.focusRow()
+}
+
+// The number of rows of the form
+func (ep *ExportPane) rows() int {
+	return exportRowColumns + len(output.Fields)
+}
+
+// focusRow focuses the path input when the cursor is on it
+func (ep *ExportPane) focusRow() tea.Cmd {
+	if ep.cursor == exportRowPath && !ep.clipboard && !ep.running {
+		return ep.path.Focus()
+	}
+	ep.path.Blur()
+	return nil
+}
+
+// move changes the row of the cursor, skipping the path when exporting to the clipboard
+func (ep *ExportPane) move(offset int) tea.Cmd {
+	ep.cursor = (ep.cursor + offset + ep.rows()) % ep.rows()
+	if ep.cursor == exportRowPath && ep.clipboard {
+		ep.cursor = (ep.cursor + offset + ep.rows()) % ep.rows()
+	}
+	return ep.focusRow()
+}
+
+/**
+ * Update changes the choice of the row under the cursor
+ * @param msg tea.KeyMsg - The key pressed by the user, starting and leaving the export are handled by the model
+ * @return tea.Cmd - The command blinking the cursor of the path
+ */
+func (ep *ExportPane) Update(msg tea.KeyMsg) tea.Cmd {
+	if ep.running {
+		return nil
+	}
+	switch {
+	case key.Matches(msg, ep.keys.Up):
+		return ep.move(-1)
+	case key.Matches(msg, ep.keys.Down):
+		return ep.move(1)
+	}
+	if ep.cursor == exportRowPath {
+		input, cmd := ep.path.Update(msg)
+		ep.path = input
+		return cmd
+	}
+	back := key.Matches(msg, ep.keys.Prev)
+	if !back && !key.Matches(msg, ep.keys.Next) {
+		return nil
+	}
+	switch ep.cursor {
+	case exportRowFormat:
+		offset := 1
+		if back {
+			offset = -1
+		}
+		previous := exportExtensions[exportFormats[ep.format]]
+		ep.format = (ep.format + offset + len(exportFormats)) % len(exportFormats)
+		// Follow the format while the path is the default one
+		if ep.path.Value() == exportFileName+"."+previous {
+			ep.path.SetValue(exportFileName + "." + exportExtensions[exportFormats[ep.format]])
+		}
+	case exportRowSource:
+		ep.all = !ep.all
+	case exportRowTarget:
+		ep.clipboard = !ep.clipboard
+	default:
+		field := output.Fields[ep.cursor-exportRowColumns]
+		ep.columns[field] = !ep.columns[field]
+	}
+	return nil
+}
+
+func (ep *ExportPane) View() string {
+	source := "loaded issues"
+	if ep.all {
+		source = "all the results of the query"
+	}
+	target := "file"
+	if ep.clipboard {
+		target = "clipboard"
+	}
+	rows := []string{
+		"Format: " + strings.ToUpper(exportFormats[ep.format]),
+		"Export: " + source,
+		"To:     " + target,
+		"Path:   " + ep.path.View(),
+	}
+	for _, field := range output.Fields {
+		check := "[ ]"
+		if ep.columns[field] {
+			check = "[x]"
+		}
+		rows = append(rows, check+" "+field)
+	}
+
+	lines := []string{ep.titleStyle.Render("Export"), ""}
+	for i, row := range rows {
+		if i == exportRowPath && ep.clipboard {
+			continue
+		}
+		if i == exportRowColumns {
+			lines = append(lines, "", "Columns:")
+		}
+		cursor := "  "
+		if i == ep.cursor {
+			cursor = "> "
+		}
+		lines = append(lines, cursor+row)
+	}
+	if ep.message != "" {
+		message := ep.message
+		if ep.failed {
+			message = ep.errorStyle.Render(message)
+		}
+		lines = append(lines, "", message)
+	}
+	return ep.style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// stateHelp lists the bindings of a state for the help, it implements help.KeyMap
+type stateHelp struct {
+	short []key.Binding
+	full  [][]key.Binding
+}
+
+func (h stateHelp) ShortHelp() []key.Binding {
+	return h.short
+}
+
+func (h stateHelp) FullHelp() [][]key.Binding {
+	return h.full
+}
+
+/**
+ * stateHelp returns the bindings active in the current state, the short help
+ * has the most used ones and the full help every one of them by context
+ * @return stateHelp - The bindings of the state
+ */
+func (m *model) stateHelp() stateHelp {
+	k := m.keys
+	global := []key.Binding{k.Global.Help, k.Global.NewTab, k.Global.ForceQuit}
+	issues := []key.Binding{
+		k.Issues.Search, k.Issues.Transition, k.Issues.Assign, k.Issues.Refresh,
+		k.Issues.RefreshInterval, k.Issues.Queue, k.Issues.Export, k.Issues.Quit,
+	}
+	issue := []key.Binding{k.Issues.Browser, k.Issues.Copy, k.Issues.Branch}
+	tabs := []key.Binding{k.Issues.NextTab, k.Issues.PrevTab, k.Issues.CloseTab}
+	layout := []key.Binding{k.Issues.GrowList, k.Issues.ShrinkList, k.Issues.ToggleList, k.Issues.ToggleCard, k.Issues.Layout}
+	switch m.state {
+	case StatusDefault:
+		navigation := []key.Binding{k.List.Up, k.List.Down, k.List.PrevPage, k.List.NextPage, k.List.Start, k.List.End}
+		list := []key.Binding{k.List.Open, k.List.Profiles, k.List.SavedQueries}
+		return stateHelp{
+			short: []key.Binding{k.List.Open, k.Issues.Search, k.Issues.Transition, k.Issues.Assign, k.Global.Help, k.Issues.Quit},
+			full:  [][]key.Binding{navigation, append(list, issue...), issues, layout, append(tabs, global...)},
+		}
+	case StatusIssueDetail:
+		detail := []key.Binding{k.Detail.Back, k.Detail.Comment}
+		return stateHelp{
+			short: []key.Binding{k.Detail.Back, k.Detail.Comment, k.Issues.Transition, k.Issues.Assign, k.Global.Help, k.Issues.Quit},
+			full:  [][]key.Binding{append(detail, issue...), issues, layout, append(tabs, global...)},
+		}
+	case StatusSearch:
+		search := []key.Binding{k.Search.Submit, k.Search.Cancel, k.Search.Older, k.Search.Newer, k.Search.History, k.Global.NewTab}
+		return stateHelp{short: search, full: [][]key.Binding{search}}
+	case StatusPicker:
+		picker := []key.Binding{k.Picker.Choose, k.Picker.Cancel}
+		return stateHelp{short: picker, full: [][]key.Binding{picker}}
+	case StatusComment:
+		comment := []key.Binding{k.Comment.Send, k.Comment.Cancel}
+		return stateHelp{short: comment, full: [][]key.Binding{comment}}
+	case StatusPrompt:
+		prompt := []key.Binding{k.Prompt.Submit, k.Prompt.Cancel}
+		if !m.prompt.Multiline() {
+			prompt = []key.Binding{k.Prompt.Confirm, k.Prompt.Cancel}
+		}
+		return stateHelp{short: prompt, full: [][]key.Binding{prompt}}
+	case StatusQueue:
+		queue := []key.Binding{k.Queue.Edit, k.Queue.Retry, k.Queue.Discard, k.Queue.Close}
+		return stateHelp{short: queue, full: [][]key.Binding{queue}}
+	case StatusExport:
+		export := []key.Binding{k.Export.Up, k.Export.Down, k.Export.Next, k.Export.Prev, k.Export.Start, k.Export.Cancel}
+		return stateHelp{short: export, full: [][]key.Binding{export}}
+	}
+	return stateHelp{}
+}
+
+// newHelp creates the help with the colors of the theme
+func newHelp(s AppStyles) help.Model {
+	h := help.New()
+	h.Styles.ShortKey = s.HelpKeyStyle
+	h.Styles.FullKey = s.HelpKeyStyle
+	h.Styles.ShortDesc = s.HelpDescStyle
+	h.Styles.FullDesc = s.HelpDescStyle
+	return h
+}
+
+// helpView shows every binding of the current state, opened with the help binding
+func (m *model) helpView() string {
+	h := m.help
+	h.Width = 0
+	return m.style.FocusedStyle.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.style.ListTitleStyle.Render("Keys"),
+		"",
+		h.FullHelpView(m.stateHelp().FullHelp()),
+	))
+}
+
+// helpFooter shows the most used bindings of the current state under the panes
+func (m *model) helpFooter() string {
+	h := m.help
+	h.Width = m.width
+	return h.ShortHelpView(m.stateHelp().ShortHelp())
+}
@@ -3,6 +3,8 @@ package app
 import (
 	"cmp"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -11,25 +13,36 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/theme"
 )
 
 type IssueCard struct {
 	style               lipgloss.Style
-	dimensions          Size
+	width               int // The size inside the border and the padding
+	height              int
 	titleStyle          lipgloss.Style
 	labelStyle          lipgloss.Style
 	valueStyle          lipgloss.Style
+	markdownStyle       string
 	issue               *jira.Issue
+	rendered            renderedDescription
 	descriptionViewport viewport.Model
 	commentBox          textarea.Model
+	commenting          bool
 }
 
+// The width of the margins of the standard glamour styles
+const glamourMargins = 2
+
+// The height of the comment box, when the card has room for it
+const commentHeight = 10
+
 func NewIssueCard() IssueCard {
 	dv := viewport.New(0, 0)
 	cm := textarea.New()
 	cm.Placeholder = "Add a comment..."
 	cm.SetWidth(50)
-	cm.SetHeight(10)
+	cm.SetHeight(commentHeight)
 	cm.ShowLineNumbers = false
 
 	return IssueCard{
@@ -37,6 +50,7 @@ func NewIssueCard() IssueCard {
 		titleStyle:          lipgloss.NewStyle(),
 		labelStyle:          lipgloss.NewStyle(),
 		valueStyle:          lipgloss.NewStyle(),
+		markdownStyle:       theme.Default().Glamour,
 		issue:               nil,
 		descriptionViewport: dv,
 		commentBox:          cm,
@@ -55,14 +69,52 @@ func (ic *IssueCard) SetLabelStyle(style lipgloss.Style) {
 	ic.labelStyle = style
 }
 
+// SetMarkdownStyle sets the glamour style of the description, a standard name or the path of a JSON style
+func (ic *IssueCard) SetMarkdownStyle(style string) {
+	ic.markdownStyle = style
+}
+
 func (ic *IssueCard) SetValueStyle(style lipgloss.Style) {
 	ic.valueStyle = style
 }
 
+/**
+ * SetSize sets the size of the card, the border and the padding of its style included
+ * @param width int - The width of the card
+ * @param height int - The height of the card
+ */
+func (ic *IssueCard) SetSize(width int, height int) {
+	ic.width = max(width-ic.style.GetHorizontalFrameSize(), 0)
+	ic.height = max(height-ic.style.GetVerticalFrameSize(), 0)
+	ic.descriptionViewport.Width = ic.width
+	ic.commentBox.SetWidth(ic.width)
+	// The comment box takes up to half of the card, the description scrolls above it
+	ic.commentBox.SetHeight(max(min(commentHeight, ic.height/2), 1))
+}
+
 func (ic *IssueCard) SetIssue(issue *jira.Issue) {
 	ic.issue = issue
 }
 
+/**
+ * StartComment shows the comment box under the issue and focuses it
+ * @return tea.Cmd - The command blinking the cursor
+ */
+func (ic *IssueCard) StartComment() tea.Cmd {
+	ic.commenting = true
+	ic.commentBox.Reset()
+	return ic.commentBox.Focus()
+}
+
+func (ic *IssueCard) StopComment() {
+	ic.commenting = false
+	ic.commentBox.Blur()
+}
+
+func (ic *IssueCard) Comment() string {
+	return ic.commentBox.Value()
+}
+
 func (ic *IssueCard) Update(msg tea.Msg) (viewport.Model, textarea.Model, tea.Cmd) {
 	descriptionModel, descriptionCmd := ic.descriptionViewport.Update(msg)
 	commentModel, commentCmd := ic.commentBox.Update(msg)
@@ -72,37 +124,138 @@ func (ic *IssueCard) Update(msg tea.Msg) (viewport.Model, textarea.Model, tea.Cm
 }
 
 func (ic *IssueCard) View() string {
+	summary, fields, description := cardContent(ic.issue)
+
+	ic.renderDescription(description)
+
+	// Card content, the long values wrap inside the card
+	lines := []string{ic.titleStyle.Width(ic.width).Render(summary)}
+	for _, f := range fields {
+		lines = append(lines, lipgloss.NewStyle().Width(ic.width).Render(
+			fmt.Sprintf("%s %s", ic.labelStyle.Render(f.label+":"), ic.valueStyle.Render(f.value)),
+		))
+	}
+	lines = append(lines, fmt.Sprintf("%s", ic.labelStyle.Render("Description:")))
+	header := lipgloss.JoinVertical(lipgloss.Left, lines...)
+
+	// The description gets the lines left by the fields and the comment box
+	free := ic.height - lipgloss.Height(header)
+	if ic.commenting {
+		free -= ic.commentBox.Height()
+	}
+	ic.descriptionViewport.Height = max(free, 0)
+	card := lipgloss.JoinVertical(lipgloss.Left, header, ic.descriptionViewport.View())
+	if ic.commenting {
+		card = lipgloss.JoinVertical(lipgloss.Left, card, ic.commentBox.View())
+	}
+	// The fields alone may not fit in a short card
+	card = lipgloss.NewStyle().MaxHeight(ic.height).Render(card)
+	return ic.style.Width(ic.width + ic.style.GetHorizontalPadding()).
+		Height(ic.height + ic.style.GetVerticalPadding()).
+		Render(card)
+}
+
+// The description rendered for the card, rendered again when the issue or the width change
+type renderedDescription struct {
+	key     string
+	updated time.Time
+	width   int
+	style   string
+}
+
+// renderDescription renders the description of the issue in the viewport, glamour is slow to run on every frame
+func (ic *IssueCard) renderDescription(description string) {
+	rendered := renderedDescription{width: ic.width, style: ic.markdownStyle}
+	if ic.issue != nil {
+		rendered.key, rendered.updated = ic.issue.Key, ic.issue.Updated
+	}
+	if rendered == ic.rendered {
+		return
+	}
+	ic.rendered = rendered
+	// glamour adds its margins around the wrapped lines
+	description, _ = RenderMarkdown(description, max(ic.width-glamourMargins, 1), ic.markdownStyle)
+	ic.descriptionViewport.SetContent(description)
+	ic.descriptionViewport.GotoTop()
+}
+
+// A labelled field shown under the summary of an issue
+type cardField struct {
+	label string
+	value string
+}
+
+/**
+ * cardContent returns what the card shows of an issue, with placeholders for the missing values
+ * @param issue *jira.Issue - The issue, nil if none is selected
+ * @return string - The summary
+ * @return []cardField - The fields shown under the summary
+ * @return string - The description, in Markdown
+ */
+func cardContent(issue *jira.Issue) (string, []cardField, string) {
 	summary := "No Summary"
 	description := "No Description"
 	status := "Unknown"
 	assignee := "Unassigned"
 	reporter := "Unknown"
 
-	if ic.issue != nil {
-		summary = cmp.Or(ic.issue.Summary, summary)
-		description = cmp.Or(ic.issue.Description, description)
-		status = cmp.Or(ic.issue.Status, status)
-		assignee = cmp.Or(ic.issue.Assignee, assignee)
-		reporter = cmp.Or(ic.issue.Reporter, reporter)
+	if issue != nil {
+		summary = cmp.Or(issue.Summary, summary)
+		description = cmp.Or(issue.Description, description)
+		status = cmp.Or(issue.Status, status)
+		assignee = cmp.Or(issue.Assignee, assignee)
+		reporter = cmp.Or(issue.Reporter, reporter)
 	}
+	return summary, []cardField{
+		{"Status", status},
+		{"Assignee", assignee},
+		{"Reporter", reporter},
+	}, description
+}
 
-	// TODO: adjust word wrap
-	r, _ := glamour.NewTermRenderer(
-		glamour.WithStandardStyle("dark"),
-		glamour.WithWordWrap(50),
-	)
-	description, _ = r.Render(description)
-	ic.descriptionViewport.SetContent(description)
+/**
+ * IssueMarkdown writes an issue as a Markdown document, with the content of
+ * the card followed by the comments
+ * @param issue jira.Issue - The issue, with its comments
+ * @return string - The Markdown document
+ */
+func IssueMarkdown(issue jira.Issue) string {
+	summary, fields, description := cardContent(&issue)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s\n\n", issue.Key, summary)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "- **%s:** %s\n", f.label, f.value)
+	}
+	fmt.Fprintf(&b, "\n## Description\n\n%s\n", strings.TrimSpace(description))
+	if len(issue.Comments) > 0 {
+		b.WriteString("\n## Comments\n")
+		for _, c := range issue.Comments {
+			b.WriteString("\n" + commentMarkdown(c))
+		}
+	}
+	return b.String()
+}
 
-	// Card content
-	card := lipgloss.JoinVertical(
-		lipgloss.Left,
-		ic.titleStyle.Render(summary),
-		fmt.Sprintf("%s %s", ic.labelStyle.Render("Status:"), ic.valueStyle.Render(status)),
-		fmt.Sprintf("%s %s", ic.labelStyle.Render("Assignee:"), ic.valueStyle.Render(assignee)),
-		fmt.Sprintf("%s %s", ic.labelStyle.Render("Reporter:"), ic.valueStyle.Render(reporter)),
-		fmt.Sprintf("%s", ic.labelStyle.Render("Description:")),
-		ic.descriptionViewport.View(),
+// commentMarkdown writes a comment as a Markdown section headed by its author and its date
+func commentMarkdown(c jira.Comment) string {
+	return fmt.Sprintf("### %s, %s\n\n%s\n", cmp.Or(c.Author, "Unknown"), c.Created.Format("2006-01-02 15:04"), strings.TrimSpace(c.Body))
+}
+
+/**
+ * RenderMarkdown formats Markdown for the terminal, as shown by the card
+ * @param markdown string - The Markdown to render
+ * @param width int - The column where the lines are wrapped
+ * @param style string - The glamour style, a standard name or the path of a JSON style
+ * @return string - The rendered text
+ * @return error - The error encountered while rendering, if any
+ */
+func RenderMarkdown(markdown string, width int, style string) (string, error) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStylePath(style),
+		glamour.WithWordWrap(width),
 	)
-	return ic.style.Render(card)
+	if err != nil {
+		return markdown, err
+	}
+	return r.Render(markdown)
 }
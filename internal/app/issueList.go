@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/keymap"
 )
 
 type IssueList struct {
@@ -15,6 +16,10 @@ type IssueList struct {
 	issuesList    list.Model
 	issues        []jira.Issue
 	selectedIssue *jira.Issue
+	changes       map[string]change // The changes not viewed yet, by issue key
+	inResults     map[string]bool   // The keys returned by the last search
+	status        string
+	statusStyle   lipgloss.Style
 }
 
 func NewIssueList() IssueList {
@@ -22,7 +27,7 @@ func NewIssueList() IssueList {
 	items := []list.Item{}
 	list := list.New(
 		items,
-		itemDelegate{},
+		newItemDelegate(DefaultStyles()),
 		15,
 		20,
 	)
@@ -35,16 +40,36 @@ func NewIssueList() IssueList {
 	list.SetFilteringEnabled(false)
 	list.SetShowStatusBar(false)
   list.SetSpinner(sp.Spinner)
+  // The keys of the app are shown in its footer, and quit is one of them
+  list.SetShowHelp(false)
+  list.KeyMap.Quit.SetEnabled(false)
+  list.KeyMap.ForceQuit.SetEnabled(false)
+  list.KeyMap.ShowFullHelp.SetEnabled(false)
+  list.KeyMap.CloseFullHelp.SetEnabled(false)
 
 	return IssueList{
     style:         lipgloss.NewStyle(),
     titleStyle:    lipgloss.NewStyle(),
+    statusStyle:   lipgloss.NewStyle(),
 		issuesList:    list,
 		issues:        []jira.Issue{},
 		selectedIssue: nil,
+		changes:       map[string]change{},
+		inResults:     map[string]bool{},
 	}
 }
 
+// SetKeys sets the bindings moving through the list, in place of the defaults of the bubbles list
+func (il *IssueList) SetKeys(keys keymap.List) {
+  k := &il.issuesList.KeyMap
+  k.CursorUp = keys.Up
+  k.CursorDown = keys.Down
+  k.PrevPage = keys.PrevPage
+  k.NextPage = keys.NextPage
+  k.GoToStart = keys.Start
+  k.GoToEnd = keys.End
+}
+
 /**
  * SetIssues sets the issues in the list
  * @param issues []jira.Issue - The issues to set
@@ -53,17 +78,151 @@ func (il *IssueList) SetIssues(issues []jira.Issue) {
   if issues == nil {
     issues = []jira.Issue{}
   }
-	il.issues = issues
-	items := []list.Item{}
-	for _, issue := range issues {
-		items = append(items, item(issue.Key))
-	}
-	il.issuesList.SetItems(items)
+  il.changes = map[string]change{}
+  il.inResults = map[string]bool{}
+  for _, issue := range issues {
+    il.inResults[issue.Key] = true
+  }
+  il.setItems(issues)
   il.issuesList.StopSpinner()
 }
 
-func (il *IssueList) StartSpinner() {
-  il.issuesList.StartSpinner()
+/**
+ * Refresh replaces the issues with the results of the same query run again,
+ * keeping the cursor on the selected issue. The new and changed issues are
+ * marked, the dropped ones are kept at the bottom, until they are viewed.
+ * @param issues []jira.Issue - The new results of the query
+ */
+func (il *IssueList) Refresh(issues []jira.Issue) {
+  previous := map[string]jira.Issue{}
+  for _, issue := range il.issues {
+    previous[issue.Key] = issue
+  }
+  changes := map[string]change{}
+  inResults := map[string]bool{}
+  for _, issue := range issues {
+    inResults[issue.Key] = true
+    old, ok := previous[issue.Key]
+    switch {
+    case !ok || il.changes[issue.Key] == changeDropped:
+      changes[issue.Key] = changeNew
+    case issueChanged(old, issue):
+      changes[issue.Key] = changeUpdated
+    case il.changes[issue.Key] != changeNone:
+      changes[issue.Key] = il.changes[issue.Key]
+    }
+  }
+  // An issue leaves the list once it has been seen as dropped
+  for _, issue := range il.issues {
+    if inResults[issue.Key] || (!il.inResults[issue.Key] && il.changes[issue.Key] != changeDropped) {
+      continue
+    }
+    changes[issue.Key] = changeDropped
+    issues = append(issues, issue)
+  }
+
+  selected := ""
+  if il.selectedIssue != nil {
+    selected = il.selectedIssue.Key
+  }
+  il.changes = changes
+  il.inResults = inResults
+  il.setItems(issues)
+  for i, issue := range issues {
+    if issue.Key == selected {
+      il.issuesList.Select(i)
+      break
+    }
+  }
+  il.updateSelection()
+}
+
+// issueChanged reports whether a refresh returned a different version of the issue
+func issueChanged(old jira.Issue, new jira.Issue) bool {
+  return !old.Updated.Equal(new.Updated) ||
+    old.Summary != new.Summary ||
+    old.Status != new.Status ||
+    old.Assignee != new.Assignee
+}
+
+/**
+ * MarkViewed removes the mark of a changed issue
+ * @param key string - The key of the issue that has been viewed
+ */
+func (il *IssueList) MarkViewed(key string) {
+  if _, ok := il.changes[key]; !ok {
+    return
+  }
+  delete(il.changes, key)
+  for i, issue := range il.issues {
+    if issue.Key == key {
+      il.issuesList.SetItem(i, issueItem{key: key})
+    }
+  }
+}
+
+/**
+ * Changes counts the marked issues, for the status of the tab
+ * @return int - The number of new, changed or dropped issues not viewed yet
+ */
+func (il *IssueList) Changes() int {
+  return len(il.changes)
+}
+
+/**
+ * Select moves the cursor to an issue
+ * @param key string - The key of the issue
+ * @return bool - False if the issue is not in the list
+ */
+func (il *IssueList) Select(key string) bool {
+  for i, issue := range il.issues {
+    if issue.Key == key {
+      il.issuesList.Select(i)
+      il.updateSelection()
+      il.MarkViewed(key)
+      return true
+    }
+  }
+  return false
+}
+
+/**
+ * Issues returns the issues of the last results, without the dropped ones
+ * @return []jira.Issue - The issues in the order of the query
+ */
+func (il *IssueList) Issues() []jira.Issue {
+  issues := []jira.Issue{}
+  for _, issue := range il.issues {
+    if il.inResults[issue.Key] {
+      issues = append(issues, issue)
+    }
+  }
+  return issues
+}
+
+func (il *IssueList) setItems(issues []jira.Issue) {
+  il.issues = issues
+  items := []list.Item{}
+  for _, issue := range issues {
+    items = append(items, issueItem{key: issue.Key, change: il.changes[issue.Key]})
+  }
+  il.issuesList.SetItems(items)
+}
+
+/**
+ * SetStatus shows a short status under the list, such as the offline indicator
+ * @param status string - The status to show, empty to hide it
+ */
+func (il *IssueList) SetStatus(status string) {
+  il.status = status
+}
+
+func (il *IssueList) SetStatusStyle(style lipgloss.Style) {
+  il.statusStyle = style
+}
+
+func (il *IssueList) StartSpinner() tea.Cmd {
+  return il.issuesList.StartSpinner()
 }
 
 /**
@@ -71,19 +230,45 @@ func (il *IssueList) StartSpinner() {
  * @param msg Msg - The message to forward to the list
  */
 func (il *IssueList) Update(msg tea.Msg) (list.Model, tea.Cmd) {
+  before := il.issuesList.Index()
   issueList, issueCmd := il.issuesList.Update(msg)
   il.issuesList = issueList
+  il.updateSelection()
+  // Moving the cursor onto a changed issue shows it in the card
+  if _, isKey := msg.(tea.KeyMsg); isKey && il.issuesList.Index() != before && il.selectedIssue != nil {
+    il.MarkViewed(il.selectedIssue.Key)
+  }
+  return il.issuesList, issueCmd
+}
+
+func (il *IssueList) updateSelection() {
 	selectedIssueIndex := il.issuesList.Index()
 	if selectedIssueIndex >= 0 && selectedIssueIndex < len(il.issues) {
 		il.selectedIssue = &il.issues[selectedIssueIndex]
 	} else {
 		il.selectedIssue = nil
 	}
-  return issueList, issueCmd
 }
 
 func (il IssueList) View() string {
-  return il.style.Render(il.issuesList.View())
+  // The status line is always there, so that the list does not jump
+  status := il.statusStyle.Width(il.issuesList.Width()).MaxHeight(1).Render(il.status)
+  // The title and the pagination of a short list do not fit, cut them
+  items := lipgloss.NewStyle().MaxHeight(il.issuesList.Height()).Render(il.issuesList.View())
+  return il.style.Render(lipgloss.JoinVertical(lipgloss.Left, items, status))
+}
+
+/**
+ * SetSize sets the size of the list, the border and the padding of its style included.
+ * A line is kept for the status.
+ * @param width int - The width of the list
+ * @param height int - The height of the list
+ */
+func (il *IssueList) SetSize(width int, height int) {
+  il.issuesList.SetSize(
+    max(width-il.style.GetHorizontalFrameSize(), 0),
+    max(height-il.style.GetVerticalFrameSize()-1, 0),
+  )
 }
 
 func (il IssueList) GetSelectedIssue() *jira.Issue {
@@ -103,3 +288,8 @@ func (il *IssueList) SetTitleStyle(style lipgloss.Style) {
   il.titleStyle = style
   il.issuesList.Styles.Title = style
 }
+
+// SetItemStyles sets the colors of the issues and of their changes from the styles of the theme
+func (il *IssueList) SetItemStyles(s AppStyles) {
+  il.issuesList.SetDelegate(newItemDelegate(s))
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// IssuePicker is a small program choosing one issue from a list, used outside of
+// the TUI such as by the git hooks
+type IssuePicker struct {
+	list   list.Model
+	issues []jira.Issue
+	chosen *jira.Issue
+}
+
+/**
+ * NewIssuePicker creates the picker of an issue
+ * @param title string - The question shown above the issues
+ * @param issues []jira.Issue - The issues to choose from
+ * @param s AppStyles - The styles of the theme
+ * @return *IssuePicker - The picker, to run with tea.NewProgram
+ */
+func NewIssuePicker(title string, issues []jira.Issue, s AppStyles) *IssuePicker {
+	items := []list.Item{}
+	for _, issue := range issues {
+		items = append(items, pickerItem(issue))
+	}
+	l := list.New(items, newItemDelegate(s), 80, min(len(items)+6, 20))
+	l.Title = title
+	l.Styles.Title = s.ListTitleStyle
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	return &IssuePicker{list: l, issues: issues}
+}
+
+// pickerItem shows an issue in the picker
+func pickerItem(issue jira.Issue) item {
+	return item(fmt.Sprintf("%s  %s", issue.Key, issue.Summary))
+}
+
+/**
+ * Chosen returns the issue chosen by the user
+ * @return *jira.Issue - The issue, nil if the user quit without choosing
+ */
+func (ip *IssuePicker) Chosen() *jira.Issue {
+	return ip.chosen
+}
+
+func (ip *IssuePicker) Init() tea.Cmd {
+	return nil
+}
+
+func (ip *IssuePicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		ip.list.SetWidth(msg.Width)
+	case tea.KeyMsg:
+		// The keys are typed in the filter while it is open
+		if ip.list.FilterState() == list.Filtering {
+			break
+		}
+		switch msg.String() {
+		case "enter":
+			// The index of the list is the one among the filtered items
+			if selected, ok := ip.list.SelectedItem().(item); ok {
+				for i := range ip.issues {
+					if pickerItem(ip.issues[i]) == selected {
+						ip.chosen = &ip.issues[i]
+					}
+				}
+			}
+			return ip, tea.Quit
+		case "esc", "q", "ctrl+c":
+			return ip, tea.Quit
+		}
+	}
+	l, cmd := ip.list.Update(msg)
+	ip.list = l
+	return ip, cmd
+}
+
+func (ip *IssuePicker) View() string {
+	return ip.list.View()
+}
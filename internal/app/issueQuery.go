@@ -1,55 +1,342 @@
 package app
 
 import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/history"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jql"
+	"github.com/SpanishInquisition49/JiraTUI/internal/keymap"
 )
 
+// Queries starting with this prefix search the text of the cached issues instead of running JQL
+const localSearchPrefix = "?"
+
 type IssueQuery struct {
-  style         lipgloss.Style
-  input         textinput.Model
+	style         lipgloss.Style
+	errorStyle    lipgloss.Style
+	tokenStyles   map[jql.TokenKind]lipgloss.Style
+	keys          keymap.Search
+	input         textinput.Model
+	err           *jql.Error
+	history       *history.History
+	historyIndex  int    // The position in the history, len(entries) when editing a new query
+	draft         string // The query being edited before stepping into the history
+	searching     bool   // True while the history is being searched with ctrl+r
+	searchPattern textinput.Model
+	matches       []string
+	matchIndex    int
+	width         int // The width of the box, 0 to fit the content
 }
 
-func NewIssueQuery(jql string) IssueQuery {
-  input := textinput.New()
-  input.Placeholder = "Search for issues..."
-  if jql != "" {
-    input.SetValue(jql)
-  }
-  return IssueQuery{
-    style: lipgloss.NewStyle(),
-    input: input,
-  }
+func NewIssueQuery(query string) IssueQuery {
+	input := textinput.New()
+	input.Placeholder = "Search for issues with JQL, or ?text to search the offline cache..."
+	if query != "" {
+		input.SetValue(query)
+	}
+	pattern := textinput.New()
+	pattern.Prompt = ""
+	return IssueQuery{
+		style:         lipgloss.NewStyle(),
+		errorStyle:    lipgloss.NewStyle(),
+		tokenStyles:   map[jql.TokenKind]lipgloss.Style{},
+		keys:          keymap.Default().Search,
+		input:         input,
+		searchPattern: pattern,
+	}
 }
 
 func (iq *IssueQuery) SetStyle(style lipgloss.Style) {
-  iq.style = style
+	iq.style = style
+}
+
+func (iq *IssueQuery) SetErrorStyle(style lipgloss.Style) {
+	iq.errorStyle = style
+}
+
+/**
+ * SetTokenStyle sets the style used to highlight a kind of JQL token
+ * @param kind jql.TokenKind - The kind of token to highlight
+ * @param style lipgloss.Style - The style to render the token with
+ */
+func (iq *IssueQuery) SetTokenStyle(kind jql.TokenKind, style lipgloss.Style) {
+	iq.tokenStyles[kind] = style
+}
+
+// SetKeys sets the bindings recalling and searching the past queries
+func (iq *IssueQuery) SetKeys(keys keymap.Search) {
+	iq.keys = keys
+}
+
+/**
+ * SetHistory sets the history used for recalling and searching past queries
+ * @param h *history.History - The history of the executed queries
+ */
+func (iq *IssueQuery) SetHistory(h *history.History) {
+	iq.history = h
+	iq.historyIndex = len(h.Entries())
+}
+
+/**
+ * SaveToHistory records the current query in the history, if any
+ * @return error - The error encountered while saving the history, if any
+ */
+func (iq *IssueQuery) SaveToHistory() error {
+	if iq.history == nil {
+		return nil
+	}
+	err := iq.history.Add(iq.input.Value())
+	iq.historyIndex = len(iq.history.Entries())
+	return err
+}
+
+/**
+ * IsSearching reports whether the history search is open. While it is,
+ * esc closes the search instead of leaving the input.
+ * @return bool - True if the history is being searched
+ */
+func (iq *IssueQuery) IsSearching() bool {
+	return iq.searching
 }
 
 func (iq *IssueQuery) Update(msg tea.Msg) tea.Cmd {
-  inputModel, cmd := iq.input.Update(msg)
-  iq.input = inputModel
-  return cmd
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && iq.input.Focused() {
+		if iq.searching {
+			return iq.updateSearch(keyMsg)
+		}
+		switch {
+		case key.Matches(keyMsg, iq.keys.Older):
+			iq.recall(-1)
+			return nil
+		case key.Matches(keyMsg, iq.keys.Newer):
+			iq.recall(1)
+			return nil
+		case key.Matches(keyMsg, iq.keys.History):
+			if iq.history != nil {
+				iq.searching = true
+				iq.searchPattern.Reset()
+				iq.searchPattern.Focus()
+				iq.findMatches()
+			}
+			return nil
+		}
+	}
+
+	before := iq.input.Value()
+	inputModel, cmd := iq.input.Update(msg)
+	iq.input = inputModel
+	// The error refers to the old query, hide it as soon as the user edits it
+	if iq.input.Value() != before {
+		iq.err = nil
+		if iq.history != nil {
+			iq.historyIndex = len(iq.history.Entries())
+		}
+	}
+	return cmd
+}
+
+// recall steps through the history, older queries for negative steps
+func (iq *IssueQuery) recall(step int) {
+	if iq.history == nil {
+		return
+	}
+	entries := iq.history.Entries()
+	index := iq.historyIndex + step
+	if index < 0 || index > len(entries) {
+		return
+	}
+	if iq.historyIndex == len(entries) {
+		iq.draft = iq.input.Value()
+	}
+	iq.historyIndex = index
+	if index == len(entries) {
+		iq.input.SetValue(iq.draft)
+	} else {
+		iq.input.SetValue(entries[index])
+	}
+	iq.input.CursorEnd()
+	iq.err = nil
+}
+
+// updateSearch handles the keys while the history is being searched, shell style
+func (iq *IssueQuery) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	if key.Matches(msg, iq.keys.History) {
+		// Step to the next, older, match
+		if iq.matchIndex+1 < len(iq.matches) {
+			iq.matchIndex++
+		}
+		return nil
+	}
+	switch msg.String() {
+	case "esc", "ctrl+g":
+		iq.closeSearch()
+		return nil
+	case "enter", "tab", "left", "right":
+		// Accept the match, enter is then handled by the app and runs the query
+		if len(iq.matches) > 0 {
+			iq.input.SetValue(iq.matches[iq.matchIndex])
+			iq.input.CursorEnd()
+			iq.err = nil
+		}
+		iq.closeSearch()
+		return nil
+	}
+
+	before := iq.searchPattern.Value()
+	patternModel, cmd := iq.searchPattern.Update(msg)
+	iq.searchPattern = patternModel
+	if iq.searchPattern.Value() != before {
+		iq.findMatches()
+	}
+	return cmd
+}
+
+func (iq *IssueQuery) findMatches() {
+	iq.matches = iq.history.Search(iq.searchPattern.Value())
+	iq.matchIndex = 0
+}
+
+func (iq *IssueQuery) closeSearch() {
+	iq.searching = false
+	iq.searchPattern.Blur()
+	iq.matches = nil
+}
+
+/**
+ * Validate checks the syntax of the query and moves the cursor to the
+ * column of the error, if any. The error is shown under the input until
+ * the query is edited.
+ * @return bool - True if the query is valid, false otherwise
+ */
+func (iq *IssueQuery) Validate() bool {
+	if iq.IsLocalSearch() {
+		iq.err = nil
+		return true
+	}
+	iq.err = jql.Validate(iq.input.Value())
+	if iq.err != nil {
+		iq.input.SetCursor(iq.err.Pos)
+		return false
+	}
+	return true
 }
 
 func (iq *IssueQuery) View() string {
-  return iq.style.Render(iq.input.View())
+	style := iq.style
+	if iq.width > 0 {
+		// The width of lipgloss includes the padding but not the border
+		style = style.Width(iq.width - style.GetHorizontalBorderSize())
+	}
+	if iq.searching {
+		return style.Render(iq.searchView())
+	}
+	content := iq.highlightedView()
+	if iq.err != nil {
+		// Point at the column of the error, taking the prompt into account
+		offset := lipgloss.Width(iq.input.Prompt) + iq.err.Pos
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			content,
+			iq.errorStyle.Render(strings.Repeat(" ", offset)+"^ "+iq.err.Msg),
+		)
+	}
+	return style.Render(content)
+}
+
+// highlightedView renders the input with every JQL token in its own style.
+// Queries longer than the input fall back to the plain textinput view, which
+// knows how to scroll horizontally.
+func (iq *IssueQuery) highlightedView() string {
+	value := []rune(iq.input.Value())
+	if len(value) == 0 || iq.IsLocalSearch() || (iq.input.Width > 0 && lipgloss.Width(string(value)) > iq.input.Width) {
+		return iq.input.View()
+	}
+
+	styles := make([]lipgloss.Style, len(value))
+	for i := range styles {
+		styles[i] = iq.input.TextStyle
+	}
+	for _, token := range jql.Tokenize(string(value)) {
+		style, ok := iq.tokenStyles[token.Kind]
+		if !ok {
+			continue
+		}
+		for i := token.Pos; i < token.Pos+len([]rune(token.Text)); i++ {
+			styles[i] = style
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(iq.input.PromptStyle.Render(iq.input.Prompt))
+	pos := iq.input.Position()
+	for i, r := range value {
+		if i == pos {
+			c := iq.input.Cursor
+			c.TextStyle = styles[i]
+			c.SetChar(string(r))
+			b.WriteString(c.View())
+			continue
+		}
+		b.WriteString(styles[i].Inline(true).Render(string(r)))
+	}
+	if pos >= len(value) {
+		c := iq.input.Cursor
+		c.SetChar(" ")
+		b.WriteString(c.View())
+	}
+	return b.String()
+}
+
+// searchView renders the history search in place of the input
+func (iq *IssueQuery) searchView() string {
+	match := ""
+	if len(iq.matches) > 0 {
+		match = iq.matches[iq.matchIndex]
+	} else if iq.searchPattern.Value() != "" {
+		return "(failed reverse-i-search)`" + iq.searchPattern.View() + "'"
+	}
+	return "(reverse-i-search)`" + iq.searchPattern.View() + "': " + match
+}
+
+/**
+ * IsLocalSearch reports whether the query is a text search of the cached issues
+ * @return bool - True if the query starts with the local search prefix
+ */
+func (iq *IssueQuery) IsLocalSearch() bool {
+	return strings.HasPrefix(iq.input.Value(), localSearchPrefix)
+}
+
+/**
+ * SetWidth sets the width of the input, the border and the padding of its style included
+ * @param width int - The width of the input
+ */
+func (iq *IssueQuery) SetWidth(width int) {
+	// The cursor takes a column after the text
+	iq.width = width
+	inner := width - iq.style.GetHorizontalFrameSize() - lipgloss.Width(iq.input.Prompt) - 1
+	iq.input.Width = max(inner, 1)
+	iq.searchPattern.Width = max(inner, 1)
 }
 
 func (iq *IssueQuery) Value() string {
-  return iq.input.Value()
+	return iq.input.Value()
 }
 
 func (iq *IssueQuery) SetValue(value string) {
-  iq.input.SetValue(value)
+	iq.input.SetValue(value)
+	iq.err = nil
 }
 
 func (iq *IssueQuery) Focus() {
-  iq.input.Focus()
+	iq.input.Focus()
 }
 
 func (iq *IssueQuery) Blur() {
-  iq.input.Blur()
+	iq.input.Blur()
+	iq.closeSearch()
 }
-
@@ -12,25 +12,63 @@ import (
 
 type item string
 
-type itemDelegate struct{}
+// How an issue changed since the previous refresh of its query
+type change uint8
+
+const (
+	changeNone change = iota
+	changeNew
+	changeUpdated
+	changeDropped
+)
+
+// issueItem is an issue of the list, marked until viewed when a refresh changed it
+type issueItem struct {
+	key    string
+	change change
+}
+
+// itemDelegate draws the entries of the lists, with the colors of the theme
+type itemDelegate struct {
+	selectedStyle lipgloss.Style
+	changeStyles  map[change]lipgloss.Style
+}
+
+// newItemDelegate creates the delegate of the lists with the given styles
+func newItemDelegate(s AppStyles) itemDelegate {
+	return itemDelegate{
+		selectedStyle: s.ItemSelectedStyle,
+		changeStyles: map[change]lipgloss.Style{
+			changeNone:    lipgloss.NewStyle(),
+			changeNew:     s.ItemNewStyle,
+			changeUpdated: s.ItemUpdatedStyle,
+			changeDropped: s.ItemDroppedStyle,
+		},
+	}
+}
 
 func (d itemDelegate) Height() int                             { return 1 }
 func (d itemDelegate) Spacing() int                            { return 0 }
 func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
-	i, ok := listItem.(item)
-	if !ok {
+	var str string
+	switch i := listItem.(type) {
+	case item:
+		str = string(i)
+	case issueItem:
+		str = i.String()
+		if index != m.Index() {
+			str = d.changeStyles[i.change].Render(str)
+		}
+	default:
 		return
 	}
 
-	str := string(i)
-
 	fn := lipgloss.NewStyle().PaddingLeft(4).Render
 	if index == m.Index() {
 		fn = func(s ...string) string {
-			return lipgloss.NewStyle().
+			return d.selectedStyle.
 				PaddingLeft(2).
-				Foreground(lipgloss.Color("10")).
 				Render("> " + strings.Join(s, " "))
 		}
 	}
@@ -41,3 +79,15 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 func (i item) FilterValue() string {
 	return string(i)
 }
+
+func (i issueItem) FilterValue() string {
+	return i.key
+}
+
+// The marks of the changed issues
+var changeMarks = map[change]string{changeNone: " ", changeNew: "+", changeUpdated: "~", changeDropped: "-"}
+
+// String prefixes the key with the mark of the change
+func (i issueItem) String() string {
+	return changeMarks[i.change] + " " + i.key
+}
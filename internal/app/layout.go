@@ -0,0 +1,317 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+// How much the list grows or shrinks with every key, in percent
+const layoutStep = 5
+
+// The bounds of the share of the list, the other panes keep some room
+const (
+	minListPercent = 10
+	maxListPercent = 90
+)
+
+// The panes sharing the space under the query
+type pane uint8
+
+const (
+	paneList pane = iota
+	paneCard
+	paneComments
+)
+
+// paneLayout is the arrangement of the panes chosen in the TUI, saved for every profile
+type paneLayout struct {
+	Arrangement string `json:"arrangement"`           // config.LayoutSplit, config.LayoutStacked or config.LayoutColumns
+	HideList    bool   `json:"hide_list,omitempty"`   // The card is shown in the place of the list
+	HideCard    bool   `json:"hide_card,omitempty"`   // The card is only shown once an issue is opened
+	ListWidth   int    `json:"list_width,omitempty"`  // The share of the list next to the card, from the config if 0
+	ListHeight  int    `json:"list_height,omitempty"` // The share of the list above the card, from the config if 0
+}
+
+// The presets offered by the layout picker
+var layoutPresetLabels = map[string]string{
+	config.LayoutSplit:   "Side by side",
+	config.LayoutStacked: "Stacked",
+	config.LayoutColumns: "Three columns with comments",
+	config.LayoutList:    "List only",
+	config.LayoutCard:    "Card only",
+}
+
+/**
+ * LayoutPath returns the path of the file where the layout of a profile is saved
+ * @param profile string - The name of the profile
+ * @return string - The path of the file under the XDG state directory
+ */
+func LayoutPath(profile string) string {
+	return filepath.Join(xdg.StateHome(), "layout", profile+".json")
+}
+
+/**
+ * loadLayout reads the layout saved by the previous session
+ * @param path string - The path of the layout file
+ * @param preset string - The preset of the config, used if no layout was saved
+ * @return paneLayout - The saved layout, the preset if there is none
+ * @return error - The error encountered while reading the file, if any
+ */
+func loadLayout(path string, preset string) (paneLayout, error) {
+	var l paneLayout
+	l.applyPreset(preset)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return l, err
+	}
+	var saved paneLayout
+	if err := json.Unmarshal(content, &saved); err != nil {
+		return l, err
+	}
+	if !slices.Contains([]string{config.LayoutSplit, config.LayoutStacked, config.LayoutColumns}, saved.Arrangement) {
+		return l, nil
+	}
+	if saved.HideList && saved.HideCard {
+		saved.HideList = false
+	}
+	// The file may have been edited, 0 keeps the share of the config
+	if saved.ListWidth != 0 {
+		saved.ListWidth = min(max(saved.ListWidth, minListPercent), maxListPercent)
+	}
+	if saved.ListHeight != 0 {
+		saved.ListHeight = min(max(saved.ListHeight, minListPercent), maxListPercent)
+	}
+	return saved, nil
+}
+
+/**
+ * saveLayout writes the layout so that it can be restored
+ * @param path string - The path of the layout file
+ * @param l paneLayout - The layout
+ * @return error - The error encountered while writing the file, if any
+ */
+func saveLayout(path string, l paneLayout) error {
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+/**
+ * applyPreset arranges the panes as a preset, keeping the shares of the list
+ * @param preset string - One of config.LayoutPresets, config.LayoutSplit if unknown
+ */
+func (l *paneLayout) applyPreset(preset string) {
+	l.HideList, l.HideCard = false, false
+	switch preset {
+	case config.LayoutStacked, config.LayoutColumns:
+		l.Arrangement = preset
+	case config.LayoutList:
+		l.Arrangement = config.LayoutSplit
+		l.HideCard = true
+	case config.LayoutCard:
+		l.Arrangement = config.LayoutSplit
+		l.HideList = true
+	default:
+		l.Arrangement = config.LayoutSplit
+	}
+}
+
+/**
+ * preset returns the preset matching the layout
+ * @return string - The preset, empty if a pane was toggled away from it
+ */
+func (l paneLayout) preset() string {
+	for _, preset := range config.LayoutPresets {
+		p := l
+		p.applyPreset(preset)
+		if p == l {
+			return preset
+		}
+	}
+	return ""
+}
+
+// stacked reports whether the panes are above one another
+func (l paneLayout) stacked() bool {
+	return l.Arrangement == config.LayoutStacked
+}
+
+/**
+ * listPercent returns the share of the list in the current arrangement
+ * @param defaults config.Layout - The layout of the config, for the shares never changed
+ * @return int - The percentage of the width, or of the height when stacked
+ */
+func (l paneLayout) listPercent(defaults config.Layout) int {
+	if l.stacked() {
+		if l.ListHeight != 0 {
+			return l.ListHeight
+		}
+		return defaults.ListHeightPercent()
+	}
+	if l.ListWidth != 0 {
+		return l.ListWidth
+	}
+	return defaults.ListWidthPercent()
+}
+
+/**
+ * growList changes the share of the list in the current arrangement
+ * @param step int - The percentage to add, negative to shrink the list
+ * @param defaults config.Layout - The layout of the config, for the shares never changed
+ */
+func (l *paneLayout) growList(step int, defaults config.Layout) {
+	percent := min(max(l.listPercent(defaults)+step, minListPercent), maxListPercent)
+	if l.stacked() {
+		l.ListHeight = percent
+	} else {
+		l.ListWidth = percent
+	}
+}
+
+/**
+ * toggle hides a pane or shows it again, the list and the card are never both hidden
+ * @param p pane - The list or the card
+ */
+func (l *paneLayout) toggle(p pane) {
+	switch p {
+	case paneList:
+		l.HideList = !l.HideList
+		l.HideCard = l.HideCard && !l.HideList
+	case paneCard:
+		l.HideCard = !l.HideCard
+		l.HideList = l.HideList && !l.HideCard
+	}
+}
+
+/**
+ * visiblePanes returns the panes shown in the current state, in order. A
+ * hidden card is shown in the place of the list while it is focused.
+ * @return []pane - The visible panes
+ */
+func (m *model) visiblePanes() []pane {
+	l := m.layout
+	cardFocused := m.state == StatusIssueDetail || m.state == StatusComment
+	panes := []pane{}
+	if !l.HideList && !(l.HideCard && cardFocused) {
+		panes = append(panes, paneList)
+	}
+	if !l.HideCard || cardFocused {
+		panes = append(panes, paneCard)
+	}
+	if l.Arrangement == config.LayoutColumns {
+		panes = append(panes, paneComments)
+	}
+	return panes
+}
+
+// changeLayout saves the layout once it was changed with the keys or the picker
+func (m *model) changeLayout(change func(l *paneLayout)) {
+	change(&m.layout)
+	if err := saveLayout(LayoutPath(m.profile), m.layout); err != nil {
+		slog.Error("Error saving the layout", "error", err)
+	}
+}
+
+// openLayoutPicker offers the presets of the layout
+func (m *model) openLayoutPicker() {
+	labels := []string{}
+	current := m.layout.preset()
+	for _, preset := range config.LayoutPresets {
+		label := layoutPresetLabels[preset]
+		if preset == current {
+			label += " (current)"
+		}
+		labels = append(labels, label)
+	}
+	m.picker.Open("Layouts", PickLayout, labels, config.LayoutPresets)
+	m.ChangeStatus(StatusPicker)
+}
+
+/**
+ * resize shares the terminal between the panes: the header, the query and the
+ * footer keep their height, the visible panes split the rest. The list takes
+ * its share of the layout, the panes after it share the rest evenly. The panes
+ * and the modals fill their space, borders included.
+ * @param m *model - The application model, sized by the last tea.WindowSizeMsg
+ */
+func resize(m *model) {
+	if m.width == 0 || m.height == 0 {
+		return
+	}
+	t := m.tab()
+	for i := range m.tabs {
+		m.tabs[i].searchInput.SetWidth(m.width)
+	}
+	chrome := lipgloss.Height(m.headerView()) + lipgloss.Height(t.searchInput.View()) + lipgloss.Height(m.helpFooter())
+	area := Size{width: m.width, height: max(m.height-chrome, 0), widthPercent: 100, heightPercent: 100}
+
+	stacked := m.layout.stacked()
+	panes := m.visiblePanes()
+	rest := area
+	for i, p := range panes {
+		size := rest
+		percent := 100 / (len(panes) - i)
+		if p == paneList && i < len(panes)-1 {
+			percent = m.layout.listPercent(m.config.Layout)
+		}
+		if stacked {
+			size.SetHeightPercent(percent)
+		} else {
+			size.SetWidthPercent(percent)
+		}
+		width, height := size.GetDimensions()
+		switch p {
+		case paneList:
+			for i := range m.tabs {
+				m.tabs[i].issuesList.SetSize(width, height)
+			}
+		case paneCard:
+			for i := range m.tabs {
+				m.tabs[i].detailCard.SetSize(width, height)
+			}
+		case paneComments:
+			m.comments.SetSize(width, height)
+		}
+		rest = size.Rest(stacked)
+	}
+
+	m.picker.SetSize(area.GetDimensions())
+	m.queuePane.SetSize(area.GetDimensions())
+	m.prompt.SetWidth(area.GetWidth())
+}
+
+// panesView renders the visible panes of the active tab
+func (m *model) panesView() string {
+	t := m.tab()
+	views := []string{}
+	for _, p := range m.visiblePanes() {
+		switch p {
+		case paneList:
+			views = append(views, t.issuesList.View())
+		case paneCard:
+			views = append(views, t.detailCard.View())
+		case paneComments:
+			views = append(views, m.comments.View())
+		}
+	}
+	if m.layout.stacked() {
+		return lipgloss.JoinVertical(lipgloss.Left, views...)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, views...)
+}
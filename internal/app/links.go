@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/browser"
+	"github.com/SpanishInquisition49/JiraTUI/internal/clipboard"
+)
+
+// statusMsg shows the result of an action under the list of a tab
+type statusMsg struct {
+	tabID  int
+	status string
+}
+
+/**
+ * openInBrowser opens the selected issue in the web UI of the instance
+ * @return tea.Cmd - The command starting the browser
+ */
+func (m *model) openInBrowser() tea.Cmd {
+	t := m.tab()
+	issue := t.issuesList.GetSelectedIssue()
+	if issue == nil {
+		return nil
+	}
+	p, _ := m.config.Profile(m.profile)
+	url, err := p.IssueURL(issue.Key)
+	if err != nil {
+		t.issuesList.SetStatus("error: " + err.Error())
+		return nil
+	}
+	id, command := t.id, m.config.Browser
+	return func() tea.Msg {
+		if err := browser.Open(command, url); err != nil {
+			return statusMsg{id, "error opening the browser: " + err.Error()}
+		}
+		return statusMsg{id, "opened " + url}
+	}
+}
+
+// openCopyPicker asks what to copy of the selected issue: its key, its URL or its key and summary
+func (m *model) openCopyPicker() {
+	issue := m.tab().issuesList.GetSelectedIssue()
+	if issue == nil {
+		return
+	}
+	values := []string{issue.Key}
+	p, _ := m.config.Profile(m.profile)
+	if url, err := p.IssueURL(issue.Key); err == nil {
+		values = append(values, url)
+	}
+	values = append(values, fmt.Sprintf("%s: %s", issue.Key, issue.Summary))
+	m.picker.Open("Copy", PickCopy, values, values)
+	m.ChangeStatus(StatusPicker)
+}
+
+/**
+ * copyText copies a text to the clipboard, with OSC 52 over SSH
+ * @param text string - The text to copy
+ * @return tea.Cmd - The command copying the text
+ */
+func (m *model) copyText(text string) tea.Cmd {
+	id := m.tab().id
+	return func() tea.Msg {
+		if err := clipboard.Copy(text); err != nil {
+			return statusMsg{id, "error copying: " + err.Error()}
+		}
+		return statusMsg{id, "copied " + text}
+	}
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The actions that can be triggered by choosing an entry of the picker
+type pickerAction uint8
+
+const (
+	PickProfile pickerAction = iota
+	PickSavedQuery
+	PickCopy
+	PickLayout
+)
+
+// A Picker lets the user choose one entry from a short list
+type Picker struct {
+	style   lipgloss.Style
+	list    list.Model
+	action  pickerAction
+	values  []string // The value of each entry, the entries only show a label
+	visible bool
+}
+
+func NewPicker() Picker {
+	l := list.New([]list.Item{}, newItemDelegate(DefaultStyles()), 40, 10)
+	l.SetFilteringEnabled(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	// Leaving is bound by the app, the list must not quit it
+	l.KeyMap.Quit.SetEnabled(false)
+	l.KeyMap.ForceQuit.SetEnabled(false)
+	return Picker{
+		style: lipgloss.NewStyle(),
+		list:  l,
+	}
+}
+
+func (p *Picker) SetStyle(style lipgloss.Style) {
+	p.style = style
+}
+
+/**
+ * SetSize sets the size of the picker, the border and the padding of its style included
+ * @param width int - The width of the picker
+ * @param height int - The height of the picker
+ */
+func (p *Picker) SetSize(width int, height int) {
+	p.list.SetSize(max(width-p.style.GetHorizontalFrameSize(), 0), max(height-p.style.GetVerticalFrameSize(), 0))
+}
+
+func (p *Picker) SetTitleStyle(style lipgloss.Style) {
+	p.list.Styles.Title = style
+}
+
+// SetItemStyles sets the colors of the entries from the styles of the theme
+func (p *Picker) SetItemStyles(s AppStyles) {
+	p.list.SetDelegate(newItemDelegate(s))
+}
+
+/**
+ * Open shows the picker with the given entries
+ * @param title string - The title of the picker
+ * @param action pickerAction - The action to run with the chosen value
+ * @param labels []string - The labels shown for the entries
+ * @param values []string - The values returned for the entries, same length as labels
+ */
+func (p *Picker) Open(title string, action pickerAction, labels []string, values []string) {
+	items := []list.Item{}
+	for _, label := range labels {
+		items = append(items, item(label))
+	}
+	p.list.Title = title
+	p.list.SetItems(items)
+	p.list.Select(0)
+	p.action = action
+	p.values = values
+	p.visible = true
+}
+
+func (p *Picker) Close() {
+	p.visible = false
+}
+
+func (p *Picker) Visible() bool {
+	return p.visible
+}
+
+func (p *Picker) Action() pickerAction {
+	return p.action
+}
+
+/**
+ * Selected returns the value of the highlighted entry
+ * @return string - The value of the entry
+ * @return bool - False if the picker is empty
+ */
+func (p *Picker) Selected() (string, bool) {
+	index := p.list.Index()
+	if index < 0 || index >= len(p.values) {
+		return "", false
+	}
+	return p.values[index], true
+}
+
+func (p *Picker) Update(msg tea.Msg) tea.Cmd {
+	l, cmd := p.list.Update(msg)
+	p.list = l
+	return cmd
+}
+
+func (p *Picker) View() string {
+	return p.style.Render(p.list.View())
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/keymap"
+)
+
+// The actions that can be triggered by submitting the prompt
+type promptAction uint8
+
+const (
+	PromptTransition promptAction = iota
+	PromptAssign
+	PromptEditEntry
+	PromptRefresh
+	PromptBranch
+	PromptAuthorize
+)
+
+// A Prompt asks the user for a value, on one line or on several
+type Prompt struct {
+	style      lipgloss.Style
+	titleStyle lipgloss.Style
+	keys       keymap.Prompt
+	title      string
+	input      textarea.Model
+	action     promptAction
+	multiline  bool
+	entryID    int // The queue entry being edited by PromptEditEntry
+}
+
+func NewPrompt() Prompt {
+	input := textarea.New()
+	input.ShowLineNumbers = false
+	input.SetWidth(50)
+	return Prompt{
+		style:      lipgloss.NewStyle(),
+		titleStyle: lipgloss.NewStyle(),
+		keys:       keymap.Default().Prompt,
+		input:      input,
+	}
+}
+
+func (p *Prompt) SetStyle(style lipgloss.Style) {
+	p.style = style
+}
+
+/**
+ * SetWidth sets the width of the prompt, the border and the padding of its style included
+ * @param width int - The width of the prompt
+ */
+func (p *Prompt) SetWidth(width int) {
+	p.input.SetWidth(max(width-p.style.GetHorizontalFrameSize(), 1))
+}
+
+func (p *Prompt) SetTitleStyle(style lipgloss.Style) {
+	p.titleStyle = style
+}
+
+// SetKeys sets the bindings submitting the prompt
+func (p *Prompt) SetKeys(keys keymap.Prompt) {
+	p.keys = keys
+}
+
+/**
+ * Open shows the prompt and focuses it
+ * @param title string - The question asked to the user
+ * @param action promptAction - The action to run with the submitted value
+ * @param value string - The initial value
+ * @param multiline bool - True to accept several lines, submitted with the submit binding only
+ * @return tea.Cmd - The command blinking the cursor
+ */
+func (p *Prompt) Open(title string, action promptAction, value string, multiline bool) tea.Cmd {
+	p.title = title
+	p.action = action
+	p.multiline = multiline
+	p.input.Reset()
+	p.input.SetHeight(1)
+	if multiline {
+		p.input.SetHeight(6)
+	}
+	p.input.SetValue(value)
+	return p.input.Focus()
+}
+
+func (p *Prompt) Close() {
+	p.input.Blur()
+}
+
+func (p *Prompt) Action() promptAction {
+	return p.action
+}
+
+func (p *Prompt) Multiline() bool {
+	return p.multiline
+}
+
+func (p *Prompt) Value() string {
+	return p.input.Value()
+}
+
+func (p *Prompt) SetEntryID(id int) {
+	p.entryID = id
+}
+
+func (p *Prompt) EntryID() int {
+	return p.entryID
+}
+
+/**
+ * IsSubmit reports whether the key submits the prompt
+ * @param msg tea.KeyMsg - The key pressed by the user
+ * @return bool - True for the submit binding, and for the confirm binding on single line prompts
+ */
+func (p *Prompt) IsSubmit(msg tea.KeyMsg) bool {
+	return key.Matches(msg, p.keys.Submit) || (!p.multiline && key.Matches(msg, p.keys.Confirm))
+}
+
+func (p *Prompt) Update(msg tea.Msg) tea.Cmd {
+	input, cmd := p.input.Update(msg)
+	p.input = input
+	return cmd
+}
+
+func (p *Prompt) View() string {
+	return p.style.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		p.titleStyle.Render(p.title),
+		p.input.View(),
+	))
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/queue"
+)
+
+// QueuePane lists the changes waiting to be sent to Jira
+type QueuePane struct {
+	style      lipgloss.Style
+	errorStyle lipgloss.Style
+	list       list.Model
+	entries    []queue.Entry
+}
+
+func NewQueuePane() QueuePane {
+	l := list.New([]list.Item{}, newItemDelegate(DefaultStyles()), 60, 12)
+	l.Title = "Queued changes"
+	l.SetFilteringEnabled(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	// Leaving is bound by the app, the list must not quit it
+	l.KeyMap.Quit.SetEnabled(false)
+	l.KeyMap.ForceQuit.SetEnabled(false)
+	return QueuePane{
+		style:      lipgloss.NewStyle(),
+		errorStyle: lipgloss.NewStyle(),
+		list:       l,
+	}
+}
+
+func (qp *QueuePane) SetStyle(style lipgloss.Style) {
+	qp.style = style
+}
+
+/**
+ * SetSize sets the size of the pane, the border and the padding of its style included.
+ * A line is kept under the list for the error of the selected entry.
+ * @param width int - The width of the pane
+ * @param height int - The height of the pane
+ */
+func (qp *QueuePane) SetSize(width int, height int) {
+	qp.list.SetSize(max(width-qp.style.GetHorizontalFrameSize(), 0), max(height-qp.style.GetVerticalFrameSize()-1, 0))
+}
+
+func (qp *QueuePane) SetTitleStyle(style lipgloss.Style) {
+	qp.list.Styles.Title = style
+}
+
+// SetItemStyles sets the colors of the entries from the styles of the theme
+func (qp *QueuePane) SetItemStyles(s AppStyles) {
+	qp.list.SetDelegate(newItemDelegate(s))
+}
+
+func (qp *QueuePane) SetErrorStyle(style lipgloss.Style) {
+	qp.errorStyle = style
+}
+
+/**
+ * SetEntries replaces the entries shown, keeping the selection if possible
+ * @param entries []queue.Entry - The queued changes
+ */
+func (qp *QueuePane) SetEntries(entries []queue.Entry) {
+	qp.entries = entries
+	items := []list.Item{}
+	for _, e := range entries {
+		// Comments can span several lines, only the first one is shown
+		value, _, _ := strings.Cut(e.Value, "\n")
+		items = append(items, item(fmt.Sprintf("[%s] %s %s: %s", e.Status, e.Kind, e.IssueKey, value)))
+	}
+	qp.list.SetItems(items)
+}
+
+/**
+ * Selected returns the highlighted entry
+ * @return queue.Entry - The entry
+ * @return bool - False if the queue is empty
+ */
+func (qp *QueuePane) Selected() (queue.Entry, bool) {
+	index := qp.list.Index()
+	if index < 0 || index >= len(qp.entries) {
+		return queue.Entry{}, false
+	}
+	return qp.entries[index], true
+}
+
+func (qp *QueuePane) Update(msg tea.Msg) tea.Cmd {
+	l, cmd := qp.list.Update(msg)
+	qp.list = l
+	return cmd
+}
+
+func (qp *QueuePane) View() string {
+	lines := []string{qp.list.View()}
+	if len(qp.entries) == 0 {
+		lines = append(lines, "Nothing to send.")
+	}
+	if e, ok := qp.Selected(); ok && e.Error != "" {
+		lines = append(lines, qp.errorStyle.Render(e.Error))
+	}
+	return qp.style.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
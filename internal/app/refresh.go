@@ -0,0 +1,80 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+)
+
+type refreshTickMsg struct {
+	tabID int
+	seq   int // The refreshSeq of the tab when the timer was started
+}
+
+/**
+ * scheduleRefresh starts the timer running the query of the tab again,
+ * stopping the pending one
+ * @return tea.Cmd - The timer, nil if the tab is not refreshed
+ */
+func (t *Tab) scheduleRefresh() tea.Cmd {
+	t.refreshSeq++
+	if t.refresh <= 0 {
+		return nil
+	}
+	msg := refreshTickMsg{tabID: t.id, seq: t.refreshSeq}
+	return tea.Tick(t.refresh, func(time.Time) tea.Msg {
+		return msg
+	})
+}
+
+// handleRefreshTick runs the query of the tab again in the background and restarts its timer
+func (m *model) handleRefreshTick(msg refreshTickMsg) tea.Cmd {
+	for i := range m.tabs {
+		t := &m.tabs[i]
+		// The timer is stale if the interval changed or the tab was closed
+		if t.id != msg.tabID || t.refreshSeq != msg.seq {
+			continue
+		}
+		if t.query == "" || strings.HasPrefix(t.query, localSearchPrefix) {
+			return t.scheduleRefresh()
+		}
+		return tea.Batch(fetchIssues(m, t, true), t.scheduleRefresh())
+	}
+	return nil
+}
+
+// refreshNow runs the query of the active tab again, marking the changes
+func (m *model) refreshNow() tea.Cmd {
+	t := m.tab()
+	if t.query == "" {
+		return nil
+	}
+	return fetchIssues(m, t, true)
+}
+
+/**
+ * setRefresh changes how often the query of the active tab is run again
+ * @param value string - The interval typed by the user, empty to stop refreshing
+ * @return tea.Cmd - The timer of the next refresh
+ */
+func (m *model) setRefresh(value string) tea.Cmd {
+	t := m.tab()
+	refresh := time.Duration(0)
+	if value = strings.TrimSpace(value); value != "" {
+		d, err := time.ParseDuration(value)
+		if err == nil {
+			err = config.ValidateRefresh(d)
+		}
+		if err != nil {
+			t.issuesList.SetStatus("invalid refresh interval: " + err.Error())
+			return nil
+		}
+		refresh = d
+	}
+	t.refresh = refresh
+	m.saveTabs()
+	return t.scheduleRefresh()
+}
@@ -1,59 +1,75 @@
 package app
 
-// TODO: add the size in the components
+// Size is the share of the available space given to a pane
 type Size struct {
-	width         uint8 // The width of the terminal
-	height        uint8 // The height of the terminal
-	widthPercent  uint8 // The perentage of the width used by the component
-	heightPercent uint8 // The perentage of the height used by the component
+	width         int // The width of the available space
+	height        int // The height of the available space
+	widthPercent  int // The perentage of the width used by the component
+	heightPercent int // The perentage of the height used by the component
 }
 
-func (s *Size) SetWidth(width uint8) {
+func (s *Size) SetWidth(width int) {
 	s.width = width
 }
 
-func (s *Size) SetHeight(height uint8) {
+func (s *Size) SetHeight(height int) {
 	s.height = height
 }
 
-func (s *Size) SetDimensions(width uint8, height uint8) {
+func (s *Size) SetDimensions(width int, height int) {
 	s.SetWidth(width)
 	s.SetHeight(height)
 }
 
-func (s *Size) SetWidthPercent(widthPercent uint8) {
+func (s *Size) SetWidthPercent(widthPercent int) {
 	s.widthPercent = widthPercent
 }
 
-func (s *Size) SetHeightPercent(heightPercent uint8) {
+func (s *Size) SetHeightPercent(heightPercent int) {
 	s.heightPercent = heightPercent
 }
 
-func (s *Size) SetDimensionsPercent(widthPercent uint8, heightPercent uint8) {
+func (s *Size) SetDimensionsPercent(widthPercent int, heightPercent int) {
 	s.SetWidthPercent(widthPercent)
 	s.SetHeightPercent(heightPercent)
 }
 
 /**
-  * GetWidth returns the width of to use for the component based on the given percentage
-  * @return uint8 - The width of the component
-  */
-func (s *Size) GetWidth() uint8 {
-  return s.width * s.widthPercent / 100
+ * GetWidth returns the width of to use for the component based on the given percentage
+ * @return int - The width of the component
+ */
+func (s *Size) GetWidth() int {
+	return s.width * s.widthPercent / 100
 }
 
 /**
-  * GetHeight returns the height of to use for the component based on the given percentage
-  * @return uint8 - The height of the component
-  */
-func (s *Size) GetHeight() uint8 {
-  return s.height * s.heightPercent / 100
+ * GetHeight returns the height of to use for the component based on the given percentage
+ * @return int - The height of the component
+ */
+func (s *Size) GetHeight() int {
+	return s.height * s.heightPercent / 100
 }
 
 /**
-  * GetDimensions returns the width and height of to use for the component based on the given percentage
-  * @return uint8, uint8 - The width and height of the component
-  */
-func (s *Size) GetDimensions() (uint8, uint8) {
-  return s.GetWidth(), s.GetHeight()
+ * GetDimensions returns the width and height of to use for the component based on the given percentage
+ * @return int, int - The width and height of the component
+ */
+func (s *Size) GetDimensions() (int, int) {
+	return s.GetWidth(), s.GetHeight()
+}
+
+/**
+ * Rest returns the space left next to the component, or under it, for the
+ * following pane. The rest of the rounding goes to that pane.
+ * @param stacked bool - True if the following pane is under the component, next to it otherwise
+ * @return Size - The space of the following pane, all of it used
+ */
+func (s *Size) Rest(stacked bool) Size {
+	rest := Size{width: s.width, height: s.height, widthPercent: 100, heightPercent: 100}
+	if stacked {
+		rest.height -= s.GetHeight()
+	} else {
+		rest.width -= s.GetWidth()
+	}
+	return rest
 }
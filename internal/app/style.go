@@ -1,33 +1,107 @@
 package app
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"log/slog"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/theme"
+)
 
 type AppStyles struct {
-	DefaultStyle lipgloss.Style
-	FocusedStyle lipgloss.Style
-	ListTitleStyle   lipgloss.Style
-  CardTitleStyle lipgloss.Style
-  CardLabelStyle lipgloss.Style
-  CardValueStyle lipgloss.Style
+	DefaultStyle       lipgloss.Style
+	FocusedStyle       lipgloss.Style
+	ListTitleStyle     lipgloss.Style
+	ListStatusStyle    lipgloss.Style
+	ItemSelectedStyle  lipgloss.Style
+	ItemNewStyle       lipgloss.Style
+	ItemUpdatedStyle   lipgloss.Style
+	ItemDroppedStyle   lipgloss.Style
+	CardTitleStyle     lipgloss.Style
+	CardLabelStyle     lipgloss.Style
+	CardValueStyle     lipgloss.Style
+	QueryFieldStyle    lipgloss.Style
+	QueryKeywordStyle  lipgloss.Style
+	QueryOperatorStyle lipgloss.Style
+	QueryStringStyle   lipgloss.Style
+	QueryFunctionStyle lipgloss.Style
+	QueryErrorStyle    lipgloss.Style
+	TabStyle           lipgloss.Style
+	ActiveTabStyle     lipgloss.Style
+	ProfileStyle       lipgloss.Style
+	QueueStatusStyle   lipgloss.Style
+	HelpKeyStyle       lipgloss.Style
+	HelpDescStyle      lipgloss.Style
+	MarkdownStyle      string // The glamour style of the descriptions
+}
+
+/**
+ * ThemeStyles creates the styles of the theme chosen in the config
+ * @param cfg *config.Config - The loaded configuration
+ * @return AppStyles - The styles, of the dark theme if the theme cannot be loaded
+ */
+func ThemeStyles(cfg *config.Config) AppStyles {
+	t, err := theme.Load(cfg.Theme)
+	if err != nil {
+		slog.Error("Error loading the theme, using the default one", "theme", cfg.Theme, "error", err)
+		t = theme.Default()
+	}
+	return NewStyles(t)
 }
 
+// DefaultStyles returns the styles of the dark theme
 func DefaultStyles() AppStyles {
+	return NewStyles(theme.Default())
+}
+
+/**
+ * NewStyles creates the styles of the application from the colors of a theme
+ * @param t theme.Theme - The theme
+ * @return AppStyles - The styles
+ */
+func NewStyles(t theme.Theme) AppStyles {
+	c := t.Colors
+	fg := func(color string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+	}
+
 	baseStyle := lipgloss.NewStyle().
 		Padding(1).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("12"))
+		Border(theme.BorderShape(t.Border)).
+		BorderForeground(lipgloss.Color(c.Border))
 
 	focuedStyle := baseStyle
-	focuedStyle = focuedStyle.BorderForeground(lipgloss.Color("11"))
+	focuedStyle = focuedStyle.
+		Border(theme.BorderShape(t.FocusedBorder)).
+		BorderForeground(lipgloss.Color(c.Focused))
 
-  titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	titleStyle := fg(c.Title)
 
 	return AppStyles{
-		DefaultStyle: baseStyle,
-		FocusedStyle: focuedStyle,
-    ListTitleStyle:   titleStyle,
-    CardTitleStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true),
-    CardLabelStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Bold(true),
-    CardValueStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+		DefaultStyle:       baseStyle,
+		FocusedStyle:       focuedStyle,
+		ListTitleStyle:     titleStyle,
+		ListStatusStyle:    fg(c.Status).Italic(true),
+		ItemSelectedStyle:  fg(c.Selected),
+		ItemNewStyle:       fg(c.New),
+		ItemUpdatedStyle:   fg(c.Updated),
+		ItemDroppedStyle:   fg(c.Dropped).Strikethrough(true),
+		CardTitleStyle:     fg(c.Summary).Bold(true),
+		CardLabelStyle:     fg(c.Label).Bold(true),
+		CardValueStyle:     fg(c.Muted),
+		QueryFieldStyle:    fg(c.Field),
+		QueryKeywordStyle:  fg(c.Keyword).Bold(true),
+		QueryOperatorStyle: fg(c.Operator),
+		QueryStringStyle:   fg(c.String),
+		QueryFunctionStyle: fg(c.Function),
+		QueryErrorStyle:    fg(c.Error),
+		TabStyle:           fg(c.Muted).Padding(0, 1),
+		ActiveTabStyle:     fg(c.Title).Padding(0, 1).Bold(true).Underline(true),
+		ProfileStyle:       fg(c.ProfileText).Padding(0, 1).Background(lipgloss.Color(c.ProfileBackground)).Reverse(c.ProfileBackground == ""),
+		QueueStatusStyle:   fg(c.Title).Padding(0, 1),
+		HelpKeyStyle:       fg(c.Title),
+		HelpDescStyle:      fg(c.Muted),
+		MarkdownStyle:      t.Glamour,
 	}
 }
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+// The maximum number of characters of the query shown in the tab bar
+const tabTitleLength = 24
+
+// A tab holds a query together with its results and the selected issue
+type Tab struct {
+	id          int
+	searchInput IssueQuery
+	issuesList  IssueList
+	detailCard  IssueCard
+	query       string        // The last query run, the input may be edited since
+	refresh     time.Duration // How often the query is run again, 0 to never
+	refreshSeq  int           // Incremented to stop the pending refresh timer
+	searchSeq   int           // Incremented by every search, the results of the previous ones are dropped
+}
+
+// savedTabs is the on-disk representation of the open tabs
+type savedTabs struct {
+	Active  int             `json:"active"`
+	Queries []string        `json:"queries"`
+	Refresh []time.Duration `json:"refresh,omitempty"` // The refresh interval of every query
+}
+
+/**
+ * TabsPath returns the path of the file where the open tabs of a profile are saved
+ * @param profile string - The name of the profile
+ * @return string - The path of the file under the XDG state directory
+ */
+func TabsPath(profile string) string {
+	return filepath.Join(xdg.StateHome(), "tabs", profile+".json")
+}
+
+/**
+ * loadTabs reads the tabs saved by the previous session
+ * @param path string - The path of the tabs file
+ * @return savedTabs - The saved tabs, without queries if there are none
+ * @return error - The error encountered while reading the file, if any
+ */
+func loadTabs(path string) (savedTabs, error) {
+	var saved savedTabs
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return saved, nil
+	} else if err != nil {
+		return saved, err
+	}
+	if err := json.Unmarshal(content, &saved); err != nil {
+		return savedTabs{}, err
+	}
+	if saved.Active < 0 || saved.Active >= len(saved.Queries) {
+		saved.Active = 0
+	}
+	// Files written before the refresh intervals existed have none
+	for len(saved.Refresh) < len(saved.Queries) {
+		saved.Refresh = append(saved.Refresh, 0)
+	}
+	return saved, nil
+}
+
+/**
+ * saveTabs writes the queries of the open tabs so that they can be restored
+ * @param path string - The path of the tabs file
+ * @param tabs []Tab - The open tabs
+ * @param active int - The index of the active tab
+ * @return error - The error encountered while writing the file, if any
+ */
+func saveTabs(path string, tabs []Tab, active int) error {
+	saved := savedTabs{Active: active, Queries: []string{}}
+	for _, t := range tabs {
+		saved.Queries = append(saved.Queries, t.searchInput.Value())
+		saved.Refresh = append(saved.Refresh, t.refresh)
+	}
+	content, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+// tabTitle returns the label of the tab in the tab bar
+func tabTitle(index int, t Tab) string {
+	title := strings.TrimSpace(t.searchInput.Value())
+	if title == "" {
+		title = "New tab"
+	}
+	if runes := []rune(title); len(runes) > tabTitleLength {
+		title = string(runes[:tabTitleLength-1]) + "…"
+	}
+	if t.refresh > 0 {
+		title += " ↻"
+	}
+	if n := t.issuesList.Changes(); n > 0 {
+		title += fmt.Sprintf(" (%d)", n)
+	}
+	return fmt.Sprintf("%d %s", index+1, title)
+}
+
+/**
+ * tabBar renders the titles of the open tabs, highlighting the active one
+ * @param tabs []Tab - The open tabs
+ * @param active int - The index of the active tab
+ * @param style lipgloss.Style - The style of the inactive tabs
+ * @param activeStyle lipgloss.Style - The style of the active tab
+ * @return string - The rendered tab bar
+ */
+func tabBar(tabs []Tab, active int, style lipgloss.Style, activeStyle lipgloss.Style) string {
+	titles := []string{}
+	for i, t := range tabs {
+		if i == active {
+			titles = append(titles, activeStyle.Render(tabTitle(i, t)))
+		} else {
+			titles = append(titles, style.Render(tabTitle(i, t)))
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Bottom, titles...)
+}
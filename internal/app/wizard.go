@@ -0,0 +1,274 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/config"
+	"github.com/SpanishInquisition49/JiraTUI/internal/credentials"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// The fields asked by the wizard, in order
+const (
+	wizardURL = iota
+	wizardEmail
+	wizardToken
+	wizardFields
+)
+
+type connectionMsg struct {
+	client *jira.Client
+	user   string
+	err    error
+}
+
+// Wizard asks for the site URL, the email and the API token, tests the
+// connection and saves the profile once it works
+type Wizard struct {
+	style      AppStyles
+	config     *config.Config
+	configPath string
+	profile    string
+	secrets    credentials.Store
+	inputs     []textinput.Model
+	focus      int
+	checking   bool
+	spinner    spinner.Model
+	problem    string // Why the last connection attempt failed
+	keepToken  bool   // The token could not be saved securely, the user agreed to keep it in the config file
+	client     *jira.Client
+	user       string
+}
+
+/**
+ * NewWizard creates the onboarding wizard for a profile using basic auth
+ * @param cfg *config.Config - The configuration the profile is saved to
+ * @param configPath string - The path where the configuration is saved
+ * @param profile string - The name of the profile to create or fix
+ * @param secrets credentials.Store - The store where the token is saved
+ * @param problem error - Why the wizard was started, nil on the first run
+ * @return *Wizard - The wizard
+ */
+func NewWizard(cfg *config.Config, configPath string, profile string, secrets credentials.Store, problem error) *Wizard {
+	p, _ := cfg.Profile(profile)
+
+	inputs := make([]textinput.Model, wizardFields)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Width = 50
+	}
+	inputs[wizardURL].Placeholder = "https://your-site.atlassian.net"
+	inputs[wizardURL].SetValue(p.URL)
+	inputs[wizardEmail].Placeholder = "you@example.com"
+	inputs[wizardEmail].SetValue(p.Auth.Email)
+	inputs[wizardToken].Placeholder = "API token"
+	inputs[wizardToken].EchoMode = textinput.EchoPassword
+	inputs[wizardToken].SetValue(p.Auth.Token)
+	inputs[wizardURL].Focus()
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+
+	w := &Wizard{
+		style:      ThemeStyles(cfg),
+		config:     cfg,
+		configPath: configPath,
+		profile:    profile,
+		secrets:    secrets,
+		inputs:     inputs,
+		spinner:    sp,
+	}
+	if problem != nil {
+		w.problem = explain(problem)
+	}
+	return w
+}
+
+// explain returns the reason of a connection error without the technical details
+func explain(err error) string {
+	var connErr *jira.ConnectionError
+	if errors.As(err, &connErr) {
+		return connErr.Reason
+	}
+	return err.Error()
+}
+
+/**
+ * Client returns the client connected with the saved profile
+ * @return *jira.Client - The client, nil if the wizard was cancelled
+ */
+func (w *Wizard) Client() *jira.Client {
+	return w.client
+}
+
+/**
+ * User returns the display name of the user the wizard connected as
+ * @return string - The display name, empty if the wizard was cancelled
+ */
+func (w *Wizard) User() string {
+	return w.user
+}
+
+func (w *Wizard) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (w *Wizard) setFocus(index int) {
+	w.inputs[w.focus].Blur()
+	w.focus = (index + wizardFields) % wizardFields
+	w.inputs[w.focus].Focus()
+}
+
+func (w *Wizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case connectionMsg:
+		w.checking = false
+		if msg.err != nil {
+			w.problem = explain(msg.err)
+			slog.Warn("Connection test failed", "error", msg.err)
+			return w, nil
+		}
+		if err := w.save(); errors.Is(err, errTokenNotSaved) {
+			// The next enter connects again and keeps the token in the config file
+			w.keepToken = true
+			w.problem = fmt.Sprintf("%s. Press enter to keep it in the config file, readable only by you", err)
+			return w, nil
+		} else if err != nil {
+			w.problem = fmt.Sprintf("the connection works but the config could not be saved: %s", err)
+			return w, nil
+		}
+		w.client, w.user = msg.client, msg.user
+		return w, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		w.spinner, cmd = w.spinner.Update(msg)
+		return w, cmd
+	case tea.KeyMsg:
+		if w.checking {
+			if msg.String() == "ctrl+c" {
+				return w, tea.Quit
+			}
+			return w, nil
+		}
+		if msg.String() != "enter" {
+			// The values changed, the token may be saved securely this time
+			w.keepToken = false
+		}
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return w, tea.Quit
+		case "tab", "down":
+			w.setFocus(w.focus + 1)
+			return w, nil
+		case "shift+tab", "up":
+			w.setFocus(w.focus - 1)
+			return w, nil
+		case "enter":
+			if w.focus < wizardToken {
+				w.setFocus(w.focus + 1)
+				return w, nil
+			}
+			return w, w.check()
+		}
+	}
+
+	var cmd tea.Cmd
+	w.inputs[w.focus], cmd = w.inputs[w.focus].Update(msg)
+	return w, cmd
+}
+
+// check tests the connection with the typed values in the background
+func (w *Wizard) check() tea.Cmd {
+	url := strings.TrimSuffix(strings.TrimSpace(w.inputs[wizardURL].Value()), "/")
+	w.inputs[wizardURL].SetValue(url)
+	if err := jira.ValidateSiteURL(url); err != nil {
+		w.problem = explain(err)
+		w.setFocus(wizardURL)
+		return nil
+	}
+	if w.inputs[wizardEmail].Value() == "" || w.inputs[wizardToken].Value() == "" {
+		w.problem = "the email and the API token are both required"
+		return nil
+	}
+
+	w.checking = true
+	w.problem = ""
+	auth := jira.BasicAuth{
+		Email: strings.TrimSpace(w.inputs[wizardEmail].Value()),
+		Token: strings.TrimSpace(w.inputs[wizardToken].Value()),
+	}
+	return tea.Batch(w.spinner.Tick, func() tea.Msg {
+		client, err := jira.NewClient(url, auth)
+		if err != nil {
+			return connectionMsg{err: err}
+		}
+		user, err := client.CheckConnection()
+		return connectionMsg{client, user, err}
+	})
+}
+
+// errTokenNotSaved is returned by save when the token could not be saved securely
+var errTokenNotSaved = errors.New("the token could not be saved securely")
+
+// save writes the working profile to the config file and the token to the credential store
+func (w *Wizard) save() error {
+	p := w.config.Profiles[w.profile]
+	p.URL = w.inputs[wizardURL].Value()
+	p.Auth = config.Auth{
+		Method: config.AuthBasic,
+		Email:  strings.TrimSpace(w.inputs[wizardEmail].Value()),
+	}
+	token := strings.TrimSpace(w.inputs[wizardToken].Value())
+	if w.keepToken {
+		// The config file is only readable by the user
+		p.Auth.Token = token
+	} else if err := w.secrets.Set(w.profile, credentials.Token, token); err != nil {
+		slog.Warn("Error saving the token", "error", err)
+		return fmt.Errorf("%w: %s", errTokenNotSaved, explain(err))
+	}
+
+	if err := config.SaveProfile(w.configPath, w.profile, p); err != nil {
+		return err
+	}
+	if w.config.Profiles == nil {
+		w.config.Profiles = map[string]config.Profile{}
+	}
+	w.config.Profiles[w.profile] = p
+	if w.config.DefaultProfile == "" {
+		w.config.DefaultProfile = w.profile
+	}
+	return nil
+}
+
+func (w *Wizard) View() string {
+	labels := []string{"Site URL", "Email", "API token"}
+	lines := []string{
+		w.style.CardTitleStyle.Render("Connect to Jira"),
+		"",
+	}
+	for i, input := range w.inputs {
+		lines = append(lines, w.style.CardLabelStyle.Render(labels[i]), input.View(), "")
+	}
+
+	switch {
+	case w.checking:
+		lines = append(lines, w.spinner.View()+" Testing the connection...")
+	case w.keepToken:
+		lines = append(lines, w.style.QueryErrorStyle.Render("Connected, but "+w.problem))
+	case w.problem != "":
+		lines = append(lines, w.style.QueryErrorStyle.Render("Cannot connect: "+w.problem))
+	default:
+		lines = append(lines, w.style.CardValueStyle.Render("Create an API token at https://id.atlassian.com/manage-profile/security/api-tokens"))
+	}
+	lines = append(lines, "", w.style.CardValueStyle.Render("enter: next/connect • tab: switch field • esc: quit"))
+
+	return w.style.FocusedStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
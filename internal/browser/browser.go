@@ -0,0 +1,60 @@
+// Package browser opens web pages with the browser of the user.
+package browser
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// The placeholder of the URL in the configured commands, the URL is appended if there is none
+const urlPlaceholder = "{url}"
+
+/**
+ * Open opens a URL in the background, without waiting for the browser to exit
+ * @param command string - The command opening the URL, such as "firefox --new-tab {url}".
+ * If empty, $BROWSER is used, then the opener of the platform.
+ * @param url string - The URL to open
+ * @return error - An error if the command could not be started
+ */
+func Open(command string, url string) error {
+	if command == "" {
+		command = os.Getenv("BROWSER")
+	}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		args = platformOpener()
+	}
+
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, urlPlaceholder) {
+			args[i] = strings.ReplaceAll(arg, urlPlaceholder, url)
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, url)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	// The output of the browser would draw over the TUI
+	cmd.Stdout, cmd.Stderr = nil, nil
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// platformOpener returns the command opening the URLs with the default browser
+func platformOpener() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler"}
+	}
+	return []string{"xdg-open"}
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+var (
+	issuesBucket  = []byte("issues")
+	queriesBucket = []byte("queries")
+)
+
+var ErrNotCached = errors.New("query not cached")
+
+// Cache keeps the issues fetched from Jira on disk so that they can be
+// shown when Jira is not reachable
+type Cache struct {
+	db *bolt.DB
+}
+
+// The results of a query, the issues are stored once in their own bucket
+type cachedQuery struct {
+	Keys     []string  `json:"keys"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+/**
+ * DefaultPath returns the path of the cache of a profile
+ * @param profile string - The name of the profile
+ * @return string - The path of the database under the XDG cache directory
+ */
+func DefaultPath(profile string) string {
+	return filepath.Join(xdg.CacheHome(), profile+".db")
+}
+
+/**
+ * Open opens the cache, creating it if needed
+ * @param path string - The path of the database
+ * @return *Cache - The opened cache
+ * @return error - The error encountered while opening the database, if any
+ */
+func Open(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	// Fail fast if another instance holds the lock instead of hanging
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{issuesBucket, queriesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Cache{db}, nil
+}
+
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+/**
+ * StoreResults saves the issues returned by a query
+ * @param query string - The JQL query
+ * @param issues []jira.Issue - The issues returned by Jira
+ * @return error - The error encountered while writing, if any
+ */
+func (c *Cache) StoreResults(query string, issues []jira.Issue) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		q := cachedQuery{Keys: []string{}, SyncedAt: time.Now()}
+		for _, issue := range issues {
+			content, err := json.Marshal(issue)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(issuesBucket).Put([]byte(issue.Key), content); err != nil {
+				return err
+			}
+			q.Keys = append(q.Keys, issue.Key)
+		}
+		content, err := json.Marshal(q)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(queriesBucket).Put([]byte(normalize(query)), content)
+	})
+}
+
+/**
+ * Results returns the issues saved for a query
+ * @param query string - The JQL query
+ * @return []jira.Issue - The issues returned by the last successful search
+ * @return time.Time - When the query was last synced
+ * @return error - ErrNotCached if the query was never run
+ */
+func (c *Cache) Results(query string) ([]jira.Issue, time.Time, error) {
+	issues := []jira.Issue{}
+	var q cachedQuery
+	err := c.db.View(func(tx *bolt.Tx) error {
+		content := tx.Bucket(queriesBucket).Get([]byte(normalize(query)))
+		if content == nil {
+			return ErrNotCached
+		}
+		if err := json.Unmarshal(content, &q); err != nil {
+			return err
+		}
+		for _, key := range q.Keys {
+			issue, err := getIssue(tx, key)
+			if err != nil {
+				return err
+			}
+			issues = append(issues, issue)
+		}
+		return nil
+	})
+	return issues, q.SyncedAt, err
+}
+
+func getIssue(tx *bolt.Tx, key string) (jira.Issue, error) {
+	var issue jira.Issue
+	content := tx.Bucket(issuesBucket).Get([]byte(key))
+	if content == nil {
+		return issue, ErrNotCached
+	}
+	err := json.Unmarshal(content, &issue)
+	return issue, err
+}
+
+/**
+ * Search looks for the words of the text in every cached issue. An issue
+ * matches when each word appears in its key, summary, description,
+ * status, assignee or reporter, ignoring the case.
+ * @param text string - The words to look for
+ * @return []jira.Issue - The matching issues, most recently updated first
+ * @return error - The error encountered while reading, if any
+ */
+func (c *Cache) Search(text string) ([]jira.Issue, error) {
+	words := strings.Fields(strings.ToLower(text))
+	result := []jira.Issue{}
+	err := c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(issuesBucket).ForEach(func(_, content []byte) error {
+			var issue jira.Issue
+			if err := json.Unmarshal(content, &issue); err != nil {
+				return err
+			}
+			if matches(issue, words) {
+				result = append(result, issue)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Updated.After(result[j].Updated)
+	})
+	return result, err
+}
+
+func matches(issue jira.Issue, words []string) bool {
+	haystack := strings.ToLower(strings.Join([]string{
+		issue.Key,
+		issue.Summary,
+		issue.Description,
+		issue.Status,
+		issue.Assignee,
+		issue.Reporter,
+	}, "\n"))
+	for _, word := range words {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize makes queries differing only in whitespace share the results
+func normalize(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,57 @@
+// Package clipboard copies text to the clipboard of the system, or to the
+// clipboard of the terminal with OSC 52 when running over SSH.
+package clipboard
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+/**
+ * Copy replaces the content of the clipboard. Over SSH, or when the clipboard
+ * tool of the system is missing or fails, the text is sent to the terminal with
+ * an OSC 52 sequence so that it lands in the clipboard of the local machine, if
+ * the terminal supports it.
+ * @param text string - The text to copy
+ * @return error - The error of the terminal
+ */
+func Copy(text string) error {
+	if !remote() && !clipboard.Unsupported {
+		err := clipboard.WriteAll(text)
+		if err == nil {
+			return nil
+		}
+		slog.Info("Clipboard not available, using the terminal", "error", err)
+	}
+	return copyOSC52(text)
+}
+
+// remote reports whether the app runs over SSH, the system clipboard would be the one of the server
+func remote() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// copyOSC52 asks the terminal to copy the text, through the multiplexer if there is one
+func copyOSC52(text string) error {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+
+	// Write to the terminal directly, the standard output belongs to the TUI
+	var out io.Writer = os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		out = tty
+	}
+	_, err := seq.WriteTo(out)
+	return err
+}
@@ -0,0 +1,507 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/credentials"
+	"github.com/SpanishInquisition49/JiraTUI/internal/git"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/keymap"
+	"github.com/SpanishInquisition49/JiraTUI/internal/theme"
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+// The name of the profile built from the environment when there is no config file
+const EnvProfile = "default"
+
+type Config struct {
+	DefaultProfile string                         `yaml:"default_profile"`
+	HistorySize    int                            `yaml:"history_size,omitempty"`
+	Browser        string                         `yaml:"browser,omitempty"`         // The command opening the issues, {url} is replaced by the URL
+	BranchTemplate string                         `yaml:"branch_template,omitempty"` // The template of the branch names, git.DefaultBranchTemplate if empty
+	Keys           map[string]map[string][]string `yaml:"keys,omitempty"`            // The keys of the actions by context, see keymap.New
+	Theme          string                         `yaml:"theme,omitempty"`           // A built-in theme or a theme file, detected from the terminal if empty
+	Layout         Layout                         `yaml:"layout,omitempty"`
+	Profiles       map[string]Profile             `yaml:"profiles"`
+}
+
+// Layout shares the space of the terminal between the panes, in percentages.
+// The layout changed in the TUI is saved for every profile and wins over this one.
+type Layout struct {
+	Preset     string `yaml:"preset,omitempty"`      // The arrangement of the panes, one of LayoutPresets, LayoutSplit if empty
+	ListWidth  int    `yaml:"list_width,omitempty"`  // The width of the list when the panes are side by side, DefaultListWidth if 0
+	ListHeight int    `yaml:"list_height,omitempty"` // The height of the list when the panes are stacked, DefaultListHeight if 0
+}
+
+// The presets of the layout, the arrangements of the list, the card and the comments
+const (
+	LayoutSplit   = "split"   // The list next to the card
+	LayoutStacked = "stacked" // The list above the card
+	LayoutColumns = "columns" // The list, the card and the comments side by side
+	LayoutList    = "list"    // The list alone, the card is shown in its place when opened
+	LayoutCard    = "card"    // The card alone, the selection still moves with the keys of the list
+)
+
+// LayoutPresets lists the presets of the layout
+var LayoutPresets = []string{LayoutSplit, LayoutStacked, LayoutColumns, LayoutList, LayoutCard}
+
+// The default shares of the list, the card gets the rest
+const (
+	DefaultListWidth  = 40
+	DefaultListHeight = 40
+)
+
+/**
+ * ListWidthPercent returns the percentage of the width given to the list when the panes are side by side
+ * @return int - The configured percentage, DefaultListWidth if not set
+ */
+func (l Layout) ListWidthPercent() int {
+	return cmp.Or(l.ListWidth, DefaultListWidth)
+}
+
+/**
+ * ListHeightPercent returns the percentage of the height given to the list when the panes are stacked
+ * @return int - The configured percentage, DefaultListHeight if not set
+ */
+func (l Layout) ListHeightPercent() int {
+	return cmp.Or(l.ListHeight, DefaultListHeight)
+}
+
+// validate checks the preset and that both panes keep some room
+func (l Layout) validate() error {
+	if l.Preset != "" && !slices.Contains(LayoutPresets, l.Preset) {
+		return fmt.Errorf("preset: unknown preset %q, use %s", l.Preset, strings.Join(LayoutPresets, ", "))
+	}
+	if err := validatePercent(l.ListWidth); err != nil {
+		return fmt.Errorf("list_width: %w", err)
+	}
+	if err := validatePercent(l.ListHeight); err != nil {
+		return fmt.Errorf("list_height: %w", err)
+	}
+	return nil
+}
+
+// validatePercent accepts the shares between 10 and 90, or 0 for the default
+func validatePercent(percent int) error {
+	if percent != 0 && (percent < 10 || percent > 90) {
+		return fmt.Errorf("%d is not between 10 and 90", percent)
+	}
+	return nil
+}
+
+// A profile holds everything needed to work with a Jira instance
+type Profile struct {
+	URL          string        `yaml:"url"`
+	Auth         Auth          `yaml:"auth"`
+	DefaultJQL   string        `yaml:"default_jql,omitempty"`
+	CommitJQL    string        `yaml:"commit_jql,omitempty"` // The issues offered by the prepare-commit-msg hook, DefaultCommitJQL if empty
+	Refresh      time.Duration `yaml:"refresh,omitempty"`    // How often the default query is run again, 0 to never
+	SavedQueries []SavedQuery  `yaml:"saved_queries,omitempty"`
+}
+
+// DefaultCommitJQL finds the issues the user is working on, offered when the branch has no key
+const DefaultCommitJQL = `assignee = currentUser() AND statusCategory = "In Progress" ORDER BY updated DESC`
+
+// The supported authentication methods
+const (
+	AuthBasic  = "basic"  // Email and API token, Jira Cloud
+	AuthBearer = "bearer" // Personal access token, Jira Data Center
+	AuthOAuth  = "oauth"  // OAuth 2.0 (3LO), Jira Cloud
+	AuthCookie = "cookie" // Username and password session, Jira Data Center
+)
+
+type Auth struct {
+	Method       string   `yaml:"method"`
+	Email        string   `yaml:"email,omitempty"`
+	Token        string   `yaml:"token,omitempty"`
+	Username     string   `yaml:"username,omitempty"`
+	Password     string   `yaml:"password,omitempty"`
+	ClientID     string   `yaml:"client_id,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	RedirectPort int      `yaml:"redirect_port,omitempty"`
+}
+
+// The port of the OAuth loopback redirect when none is configured
+const DefaultRedirectPort = 8089
+
+/**
+ * IssueURL returns the address of an issue in the web UI of the instance
+ * @param key string - The key of the issue
+ * @return string - The URL of the issue, such as https://example.atlassian.net/browse/PROJ-1
+ * @return error - An error if the profile has no URL
+ */
+func (p Profile) IssueURL(key string) (string, error) {
+	if p.URL == "" {
+		return "", errors.New("the profile has no URL")
+	}
+	return strings.TrimSuffix(p.URL, "/") + "/browse/" + url.PathEscape(key), nil
+}
+
+type SavedQuery struct {
+	Name    string        `yaml:"name"`
+	JQL     string        `yaml:"jql"`
+	Refresh time.Duration `yaml:"refresh,omitempty"` // How often the query is run again, 0 to never
+}
+
+/**
+ * DefaultPath returns the path of the config file under the XDG config directory
+ * @return string - The path of the config file
+ */
+func DefaultPath() string {
+	return filepath.Join(xdg.ConfigHome(), "config.yaml")
+}
+
+/**
+ * Load reads the config file at the given path. When the file does not
+ * exist the configuration is built from the JIRA_* environment variables,
+ * with a single profile named EnvProfile.
+ * @param path string - The path of the config file
+ * @return *Config - The loaded configuration
+ * @return error - The error encountered while reading or validating the file, if any
+ */
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return FromEnv(), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+/**
+ * FromEnv builds a configuration from the JIRA_* environment variables
+ * @return *Config - The configuration with a single profile named EnvProfile
+ */
+func FromEnv() *Config {
+	historySize, _ := strconv.Atoi(os.Getenv("JIRA_HISTORY_SIZE"))
+	return &Config{
+		DefaultProfile: EnvProfile,
+		HistorySize:    historySize,
+		Profiles: map[string]Profile{
+			EnvProfile: {
+				URL: os.Getenv("JIRA_URL"),
+				Auth: Auth{
+					Method: AuthBasic,
+					Email:  os.Getenv("JIRA_EMAIL"),
+					Token:  os.Getenv("JIRA_TOKEN"),
+				},
+				DefaultJQL: os.Getenv("JIRA_DEFAULT_JQL"),
+			},
+		},
+	}
+}
+
+func (c *Config) validate() error {
+	if len(c.Profiles) == 0 {
+		return errors.New("no profiles defined")
+	}
+	for name, p := range c.Profiles {
+		if p.Auth.Method == "" {
+			p.Auth.Method = AuthBasic
+		}
+		if p.Auth.Method == AuthOAuth && p.Auth.RedirectPort == 0 {
+			p.Auth.RedirectPort = DefaultRedirectPort
+		}
+		c.Profiles[name] = p
+		if err := p.Auth.validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		if err := ValidateRefresh(p.Refresh); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		for _, query := range p.SavedQueries {
+			if err := ValidateRefresh(query.Refresh); err != nil {
+				return fmt.Errorf("profile %q, query %q: %w", name, query.Name, err)
+			}
+		}
+	}
+	if _, err := git.ParseBranchTemplate(c.BranchTemplate); err != nil {
+		return fmt.Errorf("branch_template: %w", err)
+	}
+	if _, err := keymap.New(c.Keys); err != nil {
+		return fmt.Errorf("keys: %w", err)
+	}
+	if err := theme.Validate(c.Theme); err != nil {
+		return fmt.Errorf("theme: %w", err)
+	}
+	if err := c.Layout.validate(); err != nil {
+		return fmt.Errorf("layout: %w", err)
+	}
+	if c.DefaultProfile == "" && len(c.Profiles) == 1 {
+		c.DefaultProfile = c.ProfileNames()[0]
+	}
+	if _, ok := c.Profiles[c.DefaultProfile]; !ok {
+		return fmt.Errorf("default profile %q is not defined", c.DefaultProfile)
+	}
+	return nil
+}
+
+// The shortest refresh interval accepted, to avoid hammering the instance
+const MinRefresh = 10 * time.Second
+
+/**
+ * ValidateRefresh checks the interval a query is run again at
+ * @param refresh time.Duration - The interval, 0 to never refresh
+ * @return error - An error if the interval is negative or too short
+ */
+func ValidateRefresh(refresh time.Duration) error {
+	if refresh < 0 || (refresh > 0 && refresh < MinRefresh) {
+		return fmt.Errorf("refresh interval %s is shorter than %s", refresh, MinRefresh)
+	}
+	return nil
+}
+
+func (a Auth) validate() error {
+	switch a.Method {
+	case AuthBasic:
+	case AuthBearer:
+	case AuthCookie:
+		if a.Username == "" {
+			return errors.New("cookie auth requires a username")
+		}
+	case AuthOAuth:
+		if a.ClientID == "" || a.ClientSecret == "" {
+			return errors.New("oauth auth requires a client_id and a client_secret")
+		}
+	default:
+		return fmt.Errorf("unknown auth method %q", a.Method)
+	}
+	return nil
+}
+
+/**
+ * Authenticator returns the authentication method configured for the
+ * profile. Secrets missing from the config file are read from the store.
+ * @param name string - The name of the profile
+ * @param store credentials.Store - The store of the secrets
+ * @return jira.Authenticator - The authentication method
+ * @return error - An error if a secret is neither in the config nor in the store
+ */
+func (p Profile) Authenticator(name string, store credentials.Store) (jira.Authenticator, error) {
+	secret := func(value string, secretName string) (string, error) {
+		if value != "" {
+			return value, nil
+		}
+		value, err := store.Get(name, secretName)
+		if errors.Is(err, credentials.ErrNotFound) {
+			return "", fmt.Errorf("no %s for profile %q, run `jiratui login --profile %s`", secretName, name, name)
+		}
+		return value, err
+	}
+
+	switch p.Auth.Method {
+	case AuthBearer:
+		token, err := secret(p.Auth.Token, credentials.Token)
+		return jira.BearerAuth{Token: token}, err
+	case AuthCookie:
+		password, err := secret(p.Auth.Password, credentials.Password)
+		return jira.CookieAuth{Username: p.Auth.Username, Password: password}, err
+	case AuthOAuth:
+		return jira.OAuth{
+			ClientID:     p.Auth.ClientID,
+			ClientSecret: p.Auth.ClientSecret,
+			Scopes:       p.Auth.Scopes,
+			RedirectPort: p.Auth.RedirectPort,
+			Tokens:       oauthTokens{store, name},
+		}, nil
+	default:
+		token, err := secret(p.Auth.Token, credentials.Token)
+		return jira.BasicAuth{Email: p.Auth.Email, Token: token}, err
+	}
+}
+
+/**
+ * Connect creates a client for the Jira instance of the profile
+ * @param name string - The name of the profile
+ * @param store credentials.Store - The store of the secrets
+ * @param opts ...jira.Option - The options of the client
+ * @return *jira.Client - The client
+ * @return error - The error encountered while authenticating, if any
+ */
+func (p Profile) Connect(name string, store credentials.Store, opts ...jira.Option) (*jira.Client, error) {
+	auth, err := p.Authenticator(name, store)
+	if err != nil {
+		return nil, err
+	}
+	return jira.NewClient(p.URL, auth, opts...)
+}
+
+// oauthTokens keeps the OAuth token of a profile in the credential store
+type oauthTokens struct {
+	store   credentials.Store
+	profile string
+}
+
+func (t oauthTokens) Load() (*oauth2.Token, error) {
+	content, err := t.store.Get(t.profile, credentials.OAuth)
+	if err != nil {
+		return nil, err
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal([]byte(content), &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (t oauthTokens) Save(token *oauth2.Token) error {
+	content, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return t.store.Set(t.profile, credentials.OAuth, string(content))
+}
+
+/**
+ * SaveProfile writes a profile to the config file, creating the file if needed.
+ * Only the profile is changed, the rest of the file and its comments are kept.
+ * The profile becomes the default one when the file has none.
+ * @param path string - The path of the config file
+ * @param name string - The name of the profile
+ * @param p Profile - The profile
+ * @return error - The error encountered while reading or writing the file, if any
+ */
+func SaveProfile(path string, name string, p Profile) error {
+	var doc yaml.Node
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("parsing %s: the config is not a mapping", path)
+	}
+
+	var profile yaml.Node
+	if err := profile.Encode(p); err != nil {
+		return err
+	}
+	profiles := mappingValue(root, "profiles", &yaml.Node{Kind: yaml.MappingNode})
+	if profiles.Kind != yaml.MappingNode {
+		*profiles = yaml.Node{Kind: yaml.MappingNode}
+	}
+	mergeNode(mappingValue(profiles, name, &yaml.Node{Kind: yaml.MappingNode}), &profile)
+	if current := mappingValue(root, "default_profile", &yaml.Node{Kind: yaml.ScalarNode}); current.Value == "" {
+		*current = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// The file may contain tokens, keep it private
+	return os.WriteFile(path, out.Bytes(), 0o600)
+}
+
+/**
+ * mappingValue returns the value of a key of a YAML mapping, adding it if missing
+ * @param mapping *yaml.Node - The mapping
+ * @param key string - The key
+ * @param missing *yaml.Node - The value added when the key is missing
+ * @return *yaml.Node - The value of the key, changed in place
+ */
+func mappingValue(mapping *yaml.Node, key string, missing *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, missing)
+	return missing
+}
+
+// mergeNode replaces dst with src, keeping the order and the comments of the keys present in both
+func mergeNode(dst *yaml.Node, src *yaml.Node) {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+		return
+	}
+	values := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		values[src.Content[i].Value] = src.Content[i+1]
+	}
+	content := []*yaml.Node{}
+	// The keys missing from src are empty in the profile, they are dropped
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key := dst.Content[i]
+		if value, ok := values[key.Value]; ok {
+			mergeNode(dst.Content[i+1], value)
+			content = append(content, key, dst.Content[i+1])
+			delete(values, key.Value)
+		}
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if _, ok := values[src.Content[i].Value]; ok {
+			content = append(content, src.Content[i], src.Content[i+1])
+		}
+	}
+	dst.Content = content
+}
+
+/**
+ * Profile returns the profile with the given name, the default one if the name is empty
+ * @param name string - The name of the profile
+ * @return Profile - The profile
+ * @return error - An error if the profile does not exist
+ */
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+	return p, nil
+}
+
+/**
+ * ProfileNames returns the names of the profiles in alphabetical order
+ * @return []string - The names of the profiles
+ */
+func (c *Config) ProfileNames() []string {
+	names := []string{}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,200 @@
+package credentials
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/zalando/go-keyring"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+// The name under which the secrets are saved in the OS keyring
+const service = "jiratui"
+
+// The names of the secrets saved for a profile
+const (
+	Token    = "token"    // API token or personal access token
+	Password = "password" // Password of the cookie authentication
+	OAuth    = "oauth"    // OAuth token, serialized as JSON
+)
+
+var ErrNotFound = errors.New("credential not found")
+
+// ErrLocked is returned when the passphrase of the encrypted file is needed but cannot be asked
+var ErrLocked = errors.New("the credentials file is locked, set $" + passphraseEnv + " or start jiratui again")
+
+// The environment variable holding the passphrase of the encrypted file
+const passphraseEnv = "JIRATUI_PASSPHRASE"
+
+// The question asked on the terminal for the passphrase of the encrypted file
+const passphrasePrompt = "Passphrase of the credentials file: "
+
+// A Store saves the secrets of the profiles
+type Store interface {
+	/**
+	 * Get reads a secret of a profile
+	 * @param profile string - The name of the profile
+	 * @param name string - The name of the secret, such as Token
+	 * @return string - The secret
+	 * @return error - ErrNotFound if the secret was never saved
+	 */
+	Get(profile string, name string) (string, error)
+	Set(profile string, name string, secret string) error
+	Delete(profile string, name string) error
+}
+
+// KeyringStore saves the secrets in the OS keyring: the Secret Service on
+// Linux, the Keychain on macOS and the Credential Manager on Windows
+type KeyringStore struct{}
+
+func (KeyringStore) Get(profile string, name string) (string, error) {
+	secret, err := keyring.Get(service, key(profile, name))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return secret, err
+}
+
+func (KeyringStore) Set(profile string, name string, secret string) error {
+	return keyring.Set(service, key(profile, name), secret)
+}
+
+func (KeyringStore) Delete(profile string, name string) error {
+	err := keyring.Delete(service, key(profile, name))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func key(profile string, name string) string {
+	return profile + "/" + name
+}
+
+// fallbackStore uses the primary store and switches to the secondary one
+// as soon as the primary store is not available
+type fallbackStore struct {
+	primary   Store
+	secondary Store
+}
+
+/**
+ * WithFallback returns a store that uses secondary when primary is not
+ * available, for example when no keyring daemon is running
+ * @param primary Store - The preferred store
+ * @param secondary Store - The store to use when the preferred one fails
+ * @return Store - The combined store
+ */
+func WithFallback(primary Store, secondary Store) Store {
+	return &fallbackStore{primary, secondary}
+}
+
+func (s *fallbackStore) Get(profile string, name string) (string, error) {
+	secret, err := s.primary.Get(profile, name)
+	if err == nil {
+		return secret, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		slog.Info("Keyring not available, using the encrypted file", "error", err)
+	}
+	return s.secondary.Get(profile, name)
+}
+
+func (s *fallbackStore) Set(profile string, name string, secret string) error {
+	err := s.primary.Set(profile, name, secret)
+	if err == nil {
+		return nil
+	}
+	slog.Info("Keyring not available, using the encrypted file", "error", err)
+	return s.secondary.Set(profile, name, secret)
+}
+
+func (s *fallbackStore) Delete(profile string, name string) error {
+	// The secret may be in either store, remove it from both
+	errPrimary := s.primary.Delete(profile, name)
+	errSecondary := s.secondary.Delete(profile, name)
+	switch {
+	case errPrimary == nil && errors.Is(errSecondary, ErrNotFound):
+		return nil
+	case errPrimary == nil || errors.Is(errPrimary, ErrNotFound):
+		return errSecondary
+	case errSecondary == nil || errors.Is(errSecondary, ErrNotFound):
+		// As for Get and Set, a keyring that is not available holds nothing
+		slog.Info("Keyring not available, using the encrypted file", "error", errPrimary)
+		return errSecondary
+	}
+	return errors.Join(errPrimary, errSecondary)
+}
+
+/**
+ * DefaultFilePath returns the path of the encrypted credentials file
+ * @return string - The path of the file under the XDG state directory
+ */
+func DefaultFilePath() string {
+	return filepath.Join(xdg.StateHome(), "credentials")
+}
+
+/**
+ * Default returns the store used by the commands: the OS keyring with the
+ * encrypted file as fallback. The passphrase of the file is read from
+ * $JIRATUI_PASSPHRASE or asked on the terminal the first time it is needed.
+ * @return Store - The credential store
+ */
+func Default() Store {
+	return NewDefault(func() (string, error) {
+		if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+			return passphrase, nil
+		}
+		return ReadPassphrase(passphrasePrompt)
+	})
+}
+
+/**
+ * NewDefault returns the OS keyring with the encrypted file as fallback
+ * @param passphrase func() (string, error) - Returns the passphrase of the file
+ * @return Store - The credential store
+ */
+func NewDefault(passphrase func() (string, error)) Store {
+	return WithFallback(KeyringStore{}, NewFileStore(DefaultFilePath(), passphrase))
+}
+
+/**
+ * EnvPassphrase returns the passphrase of the encrypted file from the environment, for the stores that cannot read the terminal
+ * @return string - The passphrase
+ * @return error - ErrLocked if $JIRATUI_PASSPHRASE is not set
+ */
+func EnvPassphrase() (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", ErrLocked
+}
+
+/**
+ * Unlocked returns the default store for the TUI, which owns the terminal
+ * once started: the passphrase of the existing encrypted file is asked at
+ * once and the store never reads the terminal afterwards.
+ * @return Store - The credential store
+ * @return error - An error if the passphrase cannot be read or is wrong
+ */
+func Unlocked() (Store, error) {
+	passphrase, err := EnvPassphrase()
+	if err != nil {
+		if _, statErr := os.Stat(DefaultFilePath()); statErr != nil {
+			// Nothing to unlock, the file can only be created with the passphrase of the environment
+			return NewDefault(EnvPassphrase), nil
+		}
+		passphrase, err = ReadPassphrase(passphrasePrompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	file := NewFileStore(DefaultFilePath(), func() (string, error) { return passphrase, nil })
+	if err := file.Unlock(); err != nil {
+		return nil, err
+	}
+	return WithFallback(KeyringStore{}, file), nil
+}
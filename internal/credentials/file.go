@@ -0,0 +1,208 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// The scrypt parameters recommended for interactive logins
+const (
+	scryptN   = 1 << 15
+	scryptR   = 8
+	scryptP   = 1
+	keyLength = 32
+)
+
+// FileStore saves the secrets in a file encrypted with AES-GCM, the key
+// is derived with scrypt from a passphrase
+type FileStore struct {
+	mu         sync.Mutex
+	path       string
+	passphrase func() (string, error)
+	cached     string // The passphrase, asked only once
+}
+
+// The on-disk format of the file, the plaintext is a JSON object of the secrets
+type encryptedFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+/**
+ * NewFileStore creates a store backed by an encrypted file
+ * @param path string - The path of the file, created on the first Set
+ * @param passphrase func() (string, error) - Returns the passphrase of the file
+ * @return *FileStore - The store
+ */
+func NewFileStore(path string, passphrase func() (string, error)) *FileStore {
+	return &FileStore{path: path, passphrase: passphrase}
+}
+
+func (s *FileStore) Get(profile string, name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[key(profile, name)]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (s *FileStore) Set(profile string, name string, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	secrets[key(profile, name)] = secret
+	return s.write(secrets)
+}
+
+func (s *FileStore) Delete(profile string, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key(profile, name)]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, key(profile, name))
+	return s.write(secrets)
+}
+
+/**
+ * Unlock checks the passphrase of the file, if it exists, so that it is not asked later
+ * @return error - An error if the passphrase is wrong or cannot be read
+ */
+func (s *FileStore) Unlock() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.read()
+	return err
+}
+
+func (s *FileStore) getPassphrase() (string, error) {
+	if s.cached != "" {
+		return s.cached, nil
+	}
+	passphrase, err := s.passphrase()
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("empty passphrase")
+	}
+	s.cached = passphrase
+	return passphrase, nil
+}
+
+func (s *FileStore) read() (map[string]string, error) {
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("corrupted credentials file: %w", err)
+	}
+	gcm, err := s.cipher(f.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		// Forget the passphrase so that it is asked again
+		s.cached = ""
+		return nil, errors.New("wrong passphrase for the credentials file")
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("corrupted credentials file: %w", err)
+	}
+	return secrets, nil
+}
+
+func (s *FileStore) write(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	// A fresh salt and nonce on every write
+	f := encryptedFile{Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plaintext, nil)
+
+	content, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, content, 0o600)
+}
+
+func (s *FileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := s.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/**
+ * ReadPassphrase asks for a secret on the terminal without echoing it
+ * @param prompt string - The prompt shown to the user
+ * @return string - The secret typed by the user
+ * @return error - An error if stdin is not a terminal
+ */
+func ReadPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("cannot ask for a secret, stdin is not a terminal")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(secret), err
+}
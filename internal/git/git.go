@@ -0,0 +1,187 @@
+// Package git names branches after issues and finds the issue of the current
+// branch, running the git command of the user in the working directory.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// DefaultBranchTemplate names the branches as feature/PROJ-123-short-summary
+const DefaultBranchTemplate = "feature/{{.Key}}-{{.Summary | slug}}"
+
+// The number of words of the summary kept by slug, the branch names stay short
+const slugWords = 5
+
+// keyPattern matches the issue keys in branch names, which are often lower case
+var keyPattern = regexp.MustCompile(`(?i)\b([a-z][a-z0-9_]*-[0-9]+)`)
+
+// messageKeyPattern matches the issue keys written in commit messages, always upper case
+var messageKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9_]*-[0-9]+\b`)
+
+// The helpers of the branch templates
+var templateFuncs = template.FuncMap{
+	"slug":  Slug,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+/**
+ * ParseBranchTemplate reads a template of branch names, run with a jira.Issue
+ * @param text string - The template, DefaultBranchTemplate if empty
+ * @return *template.Template - The parsed template
+ * @return error - The error encountered while parsing the template, if any
+ */
+func ParseBranchTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultBranchTemplate
+	}
+	return template.New("branch").Funcs(templateFuncs).Parse(text)
+}
+
+/**
+ * BranchName names the branch of an issue
+ * @param text string - The template of the branch names, DefaultBranchTemplate if empty
+ * @param issue jira.Issue - The issue
+ * @return string - The name of the branch
+ * @return error - An error if the template fails or gives an invalid name
+ */
+func BranchName(text string, issue jira.Issue) (string, error) {
+	tmpl, err := ParseBranchTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, issue); err != nil {
+		return "", err
+	}
+	name := strings.Trim(strings.TrimSpace(b.String()), "-/")
+	if name == "" || strings.ContainsAny(name, " ~^:?*[\\") || strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid branch name %q", name)
+	}
+	return name, nil
+}
+
+/**
+ * Slug turns a text into a part of a branch name: the first words, lower case,
+ * without accents and separated by dashes
+ * @param text string - The text, such as the summary of an issue
+ * @return string - The slug, such as "crash-when-the-search-returns"
+ */
+func Slug(text string) string {
+	words := strings.FieldsFunc(norm.NFD.String(strings.ToLower(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r)
+	})
+	slug := []string{}
+	for _, word := range words {
+		// Drop the accents split from their letters by NFD
+		word = strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) || r > unicode.MaxASCII {
+				return -1
+			}
+			return r
+		}, word)
+		if word != "" {
+			slug = append(slug, word)
+		}
+		if len(slug) == slugWords {
+			break
+		}
+	}
+	return strings.Join(slug, "-")
+}
+
+/**
+ * DetectKey finds the issue key in a branch name
+ * @param branch string - The name of the branch, such as feature/proj-123-fix-the-crash
+ * @return string - The key, upper case, empty if the branch has none
+ */
+func DetectKey(branch string) string {
+	match := keyPattern.FindStringSubmatch(branch)
+	if match == nil {
+		return ""
+	}
+	return strings.ToUpper(match[1])
+}
+
+/**
+ * MessageHasKey tells whether a commit message already names an issue,
+ * the lines commented out by git are ignored
+ * @param message string - The commit message
+ * @return bool - True if the message has an issue key
+ */
+func MessageHasKey(message string) bool {
+	for _, line := range strings.Split(message, "\n") {
+		if !strings.HasPrefix(line, "#") && messageKeyPattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * CurrentBranch returns the branch checked out in the working directory
+ * @return string - The name of the branch, empty when the HEAD is detached
+ * @return error - An error if git fails, such as outside of a repository
+ */
+func CurrentBranch() (string, error) {
+	// symbolic-ref also names the branch of a repository without commits yet
+	if _, err := run("rev-parse", "--git-dir"); err != nil {
+		return "", err
+	}
+	branch, err := run("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		// Detached
+		return "", nil
+	}
+	return branch, nil
+}
+
+/**
+ * Checkout switches to the branch, creating it from the current HEAD if it does not exist
+ * @param name string - The name of the branch
+ * @return bool - True if the branch was created
+ * @return error - The error of git, such as local changes in the way
+ */
+func Checkout(name string) (bool, error) {
+	if _, err := run("rev-parse", "--verify", "--quiet", "refs/heads/"+name); err == nil {
+		_, err := run("switch", name)
+		return false, err
+	}
+	_, err := run("switch", "-c", name)
+	return err == nil, err
+}
+
+/**
+ * HooksDir returns the directory of the hooks of the repository, following core.hooksPath
+ * @return string - The path of the directory
+ * @return error - An error if git fails, such as outside of a repository
+ */
+func HooksDir() (string, error) {
+	return run("rev-parse", "--git-path", "hooks")
+}
+
+// run runs a git command and returns its output, the errors carry the message of git
+func run(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if message := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && message != "" {
+			return "", errors.New(strings.TrimPrefix(message, "fatal: "))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
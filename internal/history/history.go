@@ -0,0 +1,132 @@
+package history
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+// The number of queries kept when no size is configured
+const DefaultSize = 500
+
+type History struct {
+	path    string
+	size    int
+	entries []string // Oldest first, without duplicates
+}
+
+/**
+ * DefaultPath returns the path of the history file under the XDG state directory
+ * @return string - The path of the history file
+ */
+func DefaultPath() string {
+	return filepath.Join(xdg.StateHome(), "history")
+}
+
+/**
+ * Load reads the history from the given file. A missing file is not an
+ * error, the history simply starts empty and the file is created on the
+ * first Add.
+ * @param path string - The path of the history file
+ * @param size int - The maximum number of queries to keep, DefaultSize if not positive
+ * @return *History - The loaded history, usable even if an error is returned
+ * @return error - The error encountered while reading the file, if any
+ */
+func Load(path string, size int) (*History, error) {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	h := &History{path: path, size: size, entries: []string{}}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return h, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.push(scanner.Text())
+	}
+	return h, scanner.Err()
+}
+
+// push appends the query moving it to the end if it was already present
+func (h *History) push(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	for i, entry := range h.entries {
+		if entry == query {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append(h.entries, query)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+}
+
+/**
+ * Add records an executed query and saves the history to disk
+ * @param query string - The query to record
+ * @return error - The error encountered while saving the file, if any
+ */
+func (h *History) Add(query string) error {
+	h.push(query)
+	return h.save()
+}
+
+func (h *History) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	// Write a temporary file first so that a crash never truncates the history
+	tmp := h.path + ".tmp"
+	content := strings.Join(h.entries, "\n") + "\n"
+	if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+/**
+ * Entries returns the recorded queries, oldest first
+ * @return []string - The recorded queries
+ */
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+/**
+ * Search fuzzy matches the pattern against the history
+ * @param pattern string - The pattern to look for, an empty pattern matches everything
+ * @return []string - The matching queries, best and most recent matches first
+ */
+func (h *History) Search(pattern string) []string {
+	// Newest first so that ties in the score favour recent queries
+	recent := make([]string, len(h.entries))
+	for i, entry := range h.entries {
+		recent[len(h.entries)-1-i] = entry
+	}
+	if pattern == "" {
+		return recent
+	}
+
+	matches := fuzzy.Find(pattern, recent)
+	result := make([]string, len(matches))
+	for i, match := range matches {
+		result[i] = match.Str
+	}
+	return result
+}
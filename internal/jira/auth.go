@@ -0,0 +1,65 @@
+package jira
+
+import (
+	"net/http"
+	"strings"
+
+	jira "github.com/andygrunwald/go-jira"
+)
+
+// An Authenticator builds the HTTP client used to reach the Jira REST API
+type Authenticator interface {
+	/**
+	 * Authenticate returns the client to use for the requests to the site
+	 * @param siteURL string - The URL of the Jira site
+	 * @return *http.Client - The client adding the credentials to the requests
+	 * @return string - The base URL of the REST API, usually the site URL
+	 * @return error - The error encountered while authenticating, if any
+	 */
+	Authenticate(siteURL string) (*http.Client, string, error)
+}
+
+// BasicAuth authenticates with an email and an API token, as used by Jira Cloud
+type BasicAuth struct {
+	Email string
+	Token string
+}
+
+func (a BasicAuth) Authenticate(siteURL string) (*http.Client, string, error) {
+	tp := jira.BasicAuthTransport{
+		Username:  a.Email,
+		Password:  a.Token,
+		Transport: wireTransport,
+	}
+	return tp.Client(), siteURL, nil
+}
+
+// BearerAuth authenticates with a personal access token, as used by Jira Data Center
+type BearerAuth struct {
+	Token string
+}
+
+func (a BearerAuth) Authenticate(siteURL string) (*http.Client, string, error) {
+	tp := jira.BearerAuthTransport{
+		Token:     a.Token,
+		Transport: wireTransport,
+	}
+	return tp.Client(), siteURL, nil
+}
+
+// CookieAuth opens a session with a username and a password, the session
+// cookie is then sent with every request
+type CookieAuth struct {
+	Username string
+	Password string
+}
+
+func (a CookieAuth) Authenticate(siteURL string) (*http.Client, string, error) {
+	tp := jira.CookieAuthTransport{
+		Username:  a.Username,
+		Password:  a.Password,
+		AuthURL:   strings.TrimSuffix(siteURL, "/") + "/rest/auth/1/session",
+		Transport: wireTransport,
+	}
+	return tp.Client(), siteURL, nil
+}
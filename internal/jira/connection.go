@@ -0,0 +1,109 @@
+package jira
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// ConnectionError explains why the connection to the Jira site failed
+type ConnectionError struct {
+	Reason string // A short explanation for the user
+	Err    error  // The underlying error
+}
+
+func (e *ConnectionError) Error() string {
+	if e.Err == nil {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s (%s)", e.Reason, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+/**
+ * ValidateSiteURL checks that the URL can be the address of a Jira site
+ * @param siteURL string - The URL to check
+ * @return error - A ConnectionError explaining what is wrong with the URL, if anything
+ */
+func ValidateSiteURL(siteURL string) error {
+	if siteURL == "" {
+		return &ConnectionError{Reason: "the site URL is missing"}
+	}
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return &ConnectionError{Reason: "the site URL is malformed", Err: err}
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return &ConnectionError{Reason: "the site URL must start with https://"}
+	}
+	if u.Host == "" {
+		return &ConnectionError{Reason: "the site URL has no host name"}
+	}
+	return nil
+}
+
+/**
+ * CheckConnection calls /myself to verify the URL and the credentials
+ * @return string - The display name of the authenticated user
+ * @return error - A ConnectionError explaining the failure, if any
+ */
+func (j Client) CheckConnection() (string, error) {
+	base := j.client.GetBaseURL()
+	if err := ValidateSiteURL(base.String()); err != nil {
+		return "", err
+	}
+
+	user, resp, err := j.client.User.GetSelf()
+	if err == nil {
+		return user.DisplayName, nil
+	}
+	if resp != nil {
+		return "", explainStatus(resp.StatusCode, err)
+	}
+	return "", explainNetworkError(err)
+}
+
+func explainStatus(status int, err error) error {
+	switch status {
+	case http.StatusUnauthorized:
+		return &ConnectionError{Reason: "the site rejected the credentials, check the email and the API token", Err: err}
+	case http.StatusForbidden:
+		return &ConnectionError{Reason: "the account is not allowed to use the REST API, too many failed logins may require a CAPTCHA in the browser", Err: err}
+	case http.StatusNotFound:
+		return &ConnectionError{Reason: "the URL does not point to a Jira site", Err: err}
+	case http.StatusTooManyRequests:
+		return &ConnectionError{Reason: "the site is rate limiting the requests, try again later", Err: err}
+	}
+	if status >= 500 {
+		return &ConnectionError{Reason: "the Jira site is having problems, try again later", Err: err}
+	}
+	return &ConnectionError{Reason: fmt.Sprintf("unexpected answer from the site (HTTP %d)", status), Err: err}
+}
+
+func explainNetworkError(err error) error {
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		return err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound && !dnsErr.IsTemporary {
+		return &ConnectionError{Reason: fmt.Sprintf("the host %s does not exist, check the site URL", dnsErr.Name), Err: err}
+	}
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthority) {
+		return &ConnectionError{Reason: "the certificate of the site is not trusted", Err: err}
+	}
+	// Without a response the site may just be unreachable, let the caller work offline
+	return &ConnectionError{
+		Reason: "cannot reach the site, check the network connection",
+		Err:    fmt.Errorf("%w: %w", ErrOffline, err),
+	}
+}
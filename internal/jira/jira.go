@@ -1,24 +1,40 @@
 package jira
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	jira "github.com/andygrunwald/go-jira"
-	"github.com/google/go-cmp/cmp"
 )
 
 type Client struct {
-	client *jira.Client
+	client    *jira.Client
+	transport *RetryTransport
 }
 
 type Issue struct {
-	Key         string
-	Summary     string
-	Assignee    string
-	Status      string
-	Reporter    string
-	Description string
+	Key         string    `json:"key"`
+	Summary     string    `json:"summary"`
+	Assignee    string    `json:"assignee"`
+	Status      string    `json:"status"`
+	Reporter    string    `json:"reporter"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+	Priority    string    `json:"priority"`
+	Labels      []string  `json:"labels"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Comments    []Comment `json:"comments,omitempty"` // Set by GetIssue, the searches of Jira Cloud do not return them
+}
+
+type Comment struct {
+	Author  string    `json:"author"`
+	Body    string    `json:"body"`
+	Created time.Time `json:"created"`
 }
 
 /**
@@ -29,69 +45,298 @@ type Issue struct {
  * @return Jira - A new Jira client
  */
 func CreateClient(email string, api_token string, url string) *Client {
-	tp := jira.BasicAuthTransport{
-		Username: email,
-		Password: api_token,
+	client, err := NewClient(url, BasicAuth{Email: email, Token: api_token})
+	if err != nil {
+		slog.Error("Error creating Jira client", "error", err)
+		return nil
 	}
+	return client
+}
 
-	client, err := jira.NewClient(tp.Client(), url)
+/**
+ * Create a new Jira client with the given authentication method
+ * @param url string - The URL of the Jira instance to connect to
+ * @param auth Authenticator - The authentication method to use
+ * @param opts ...Option - The options of the client, such as WithRecording
+ * @return *Client - A new Jira client
+ * @return error - The error encountered while authenticating, if any
+ */
+func NewClient(url string, auth Authenticator, opts ...Option) (*Client, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := ValidateSiteURL(url); err != nil {
+		return nil, err
+	}
+	httpClient, baseURL, err := auth.Authenticate(url)
 	if err != nil {
-		log.Println(fmt.Sprintf("Error creating Jira client: %s", err))
-		return nil
+		return nil, err
 	}
-	return &Client{client}
+	// Retry on top of the authentication so that every attempt is authenticated
+	transport := NewRetryTransport(httpClient.Transport)
+	retrying := *httpClient
+	retrying.Transport = transport
+	if o.recordPath != "" {
+		// Record the final responses, not the attempts rejected by the rate limit
+		rec, err := newRecorder(transport, baseURL, url, o.recordPath)
+		if err != nil {
+			return nil, err
+		}
+		retrying.Transport = rec
+	}
+	client, err := jira.NewClient(&retrying, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client, transport}, nil
 }
 
+/**
+ * RetryState returns the requests waiting for the rate limit or for a temporary error
+ * @return RetryState - The retry state of the client
+ */
+func (j Client) RetryState() RetryState {
+	return j.transport.State()
+}
+
+// ErrOffline is wrapped by the errors of the requests that got no response
+var ErrOffline = errors.New("Jira is not reachable")
+
 /**
  * Search for issues in Jira
  * @param jql string - The JQL query to search for issues
- * @return []jira.Issue - A list of issues matching the JQL query or nil if an error occurred
+ * @return []jira.Issue - A list of issues matching the JQL query
+ * @return error - The error returned by Jira, wrapping ErrOffline if there was no response
  */
-func (j Client) SearchIssues(jql string) []Issue {
-	issues, _, err := j.client.Issue.Search(jql, nil)
+func (j Client) SearchIssues(jql string) ([]Issue, error) {
+	issues, resp, err := j.client.Issue.Search(jql, nil)
 	if err != nil {
-		log.Println(fmt.Sprintf("Error searching for issues: %s", err))
-		return nil
+		slog.Warn("Error searching for issues", "jql", jql, "error", err)
+		return nil, wrapError(resp, err)
 	}
 
-	var result []Issue
+	result := []Issue{}
 	for _, issue := range issues {
-		// Check if fields are nil to avoid panics using the cmp package
-		i := Issue{
-			Key:         issue.Key,
-			Assignee:    "",
-			Reporter:    "",
-			Description: "",
-			Status:      "",
-		}
-		if issue.Fields.Assignee != nil {
-			i.Assignee = issue.Fields.Assignee.DisplayName
+		result = append(result, convertIssue(issue))
+	}
+	return result, nil
+}
+
+// The page size asked to Jira by SearchAll, the largest allowed by Jira Cloud
+const SearchPageSize = 100
+
+/**
+ * SearchAll searches for issues in Jira, following the pages of the results
+ * @param jql string - The JQL query to search for issues
+ * @param limit int - The maximum number of issues to return, 0 for all of them
+ * @return []Issue - The issues matching the JQL query, in the order of the query
+ * @return error - The error returned by Jira, wrapping ErrOffline if there was no response
+ */
+func (j Client) SearchAll(jql string, limit int) ([]Issue, error) {
+	result := []Issue{}
+	for {
+		size := SearchPageSize
+		if limit > 0 {
+			size = min(size, limit-len(result))
 		}
-		if issue.Fields.Reporter != nil {
-			i.Reporter = issue.Fields.Reporter.DisplayName
+		issues, total, err := j.SearchPage(jql, len(result), size)
+		if err != nil {
+			return nil, err
 		}
-		if issue.Fields.Description != "" {
-			i.Description = issue.Fields.Description
+		result = append(result, issues...)
+		// Jira may return less issues than asked, only the total tells when to stop
+		if len(issues) == 0 || len(result) >= total || (limit > 0 && len(result) >= limit) {
+			return result, nil
 		}
-		if issue.Fields.Status != nil {
-			i.Status = issue.Fields.Status.Name
+	}
+}
+
+/**
+ * SearchPage returns a page of the issues matching a query
+ * @param jql string - The JQL query to search for issues
+ * @param startAt int - The index of the first issue of the page
+ * @param maxResults int - The size of the page, Jira may return less issues
+ * @return []Issue - The issues of the page
+ * @return int - The number of issues matching the query, on every page
+ * @return error - The error returned by Jira, wrapping ErrOffline if there was no response
+ */
+func (j Client) SearchPage(jql string, startAt int, maxResults int) ([]Issue, int, error) {
+	issues, resp, err := j.client.Issue.Search(jql, &jira.SearchOptions{StartAt: startAt, MaxResults: maxResults})
+	if err != nil {
+		slog.Warn("Error searching for issues", "jql", jql, "start", startAt, "error", err)
+		return nil, 0, wrapError(resp, err)
+	}
+	result := []Issue{}
+	for _, issue := range issues {
+		result = append(result, convertIssue(issue))
+	}
+	return result, resp.Total, nil
+}
+
+// convertIssue copies the fields used by the app, checking for nil fields to avoid panics
+func convertIssue(issue jira.Issue) Issue {
+	i := Issue{
+		Key: issue.Key,
+	}
+	if issue.Fields == nil {
+		return i
+	}
+	i.Summary = issue.Fields.Summary
+	i.Description = issue.Fields.Description
+	i.Labels = issue.Fields.Labels
+	i.Created = time.Time(issue.Fields.Created)
+	i.Updated = time.Time(issue.Fields.Updated)
+	i.Type = issue.Fields.Type.Name
+	if issue.Fields.Priority != nil {
+		i.Priority = issue.Fields.Priority.Name
+	}
+	if issue.Fields.Assignee != nil {
+		i.Assignee = issue.Fields.Assignee.DisplayName
+	}
+	if issue.Fields.Reporter != nil {
+		i.Reporter = issue.Fields.Reporter.DisplayName
+	}
+	if issue.Fields.Status != nil {
+		i.Status = issue.Fields.Status.Name
+	}
+	if issue.Fields.Comments != nil {
+		for _, c := range issue.Fields.Comments.Comments {
+			if c == nil {
+				continue
+			}
+			comment := Comment{Author: c.Author.DisplayName, Body: c.Body}
+			// Jira sends the dates of the comments as text, in its own format
+			if created, err := time.Parse("2006-01-02T15:04:05.000-0700", c.Created); err == nil {
+				comment.Created = created
+			}
+			i.Comments = append(i.Comments, comment)
 		}
-		result = append(result, i)
 	}
-	return result
+	return i
+}
+
+/**
+ * Get an issue with all its fields and its comments
+ * @param key string - The key of the issue
+ * @return Issue - The issue
+ * @return error - The error returned by Jira, wrapping ErrOffline if there was no response
+ */
+func (j Client) GetIssue(key string) (Issue, error) {
+	issue, resp, err := j.client.Issue.Get(key, nil)
+	if err != nil {
+		slog.Warn("Error getting issue", "issue", key, "error", err)
+		return Issue{}, wrapError(resp, err)
+	}
+	return convertIssue(*issue), nil
+}
+
+/**
+ * Add a comment to a Jira issue
+ * @param key string - The key of the issue to add the comment to
+ * @param comment string - The comment to add to the issue
+ * @return error - The error returned by Jira, wrapping ErrOffline if there was no response
+ */
+func (j Client) AddComment(key string, comment string) error {
+	_, resp, err := j.client.Issue.AddComment(key, &jira.Comment{Body: comment})
+	if err != nil {
+		slog.Warn("Error adding comment to issue", "issue", key, "error", err)
+	}
+	return wrapError(resp, err)
 }
 
 /**
-* Add a comment to a Jira issue
- * @param issue Issue - The issue to add the comment to
-* @param comment string - The comment to add to the issue
-* @return bool - True if the comment was added successfully, false otherwise
-*/
-func (j Client) AddComment(issue Issue, comment string) bool {
-	_, _, err := j.client.Issue.AddComment(issue.Key, &jira.Comment{Body: comment})
+ * Get the time of the last update of an issue, used to detect conflicting changes
+ * @param key string - The key of the issue
+ * @return time.Time - When the issue was last updated
+ * @return error - The error returned by Jira, wrapping ErrOffline if there was no response
+ */
+func (j Client) IssueUpdated(key string) (time.Time, error) {
+	issue, resp, err := j.client.Issue.Get(key, &jira.GetQueryOptions{Fields: "updated"})
+	if err != nil {
+		return time.Time{}, wrapError(resp, err)
+	}
+	return convertIssue(*issue).Updated, nil
+}
+
+/**
+ * Move an issue to another status
+ * @param key string - The key of the issue
+ * @param status string - The name of the target status or of the transition, case insensitive
+ * @return error - An error if no transition leads to the status or if Jira fails
+ */
+func (j Client) TransitionIssue(key string, status string) error {
+	transitions, resp, err := j.client.Issue.GetTransitions(key)
 	if err != nil {
-		log.Println(fmt.Sprintf("Error adding comment to issue %s: %s", issue.Key, err))
-		return false
+		return wrapError(resp, err)
 	}
-	return true
+	for _, t := range transitions {
+		if strings.EqualFold(t.To.Name, status) || strings.EqualFold(t.Name, status) {
+			resp, err := j.client.Issue.DoTransition(key, t.ID)
+			return wrapError(resp, err)
+		}
+	}
+	return fmt.Errorf("no transition of %s leads to %q", key, status)
+}
+
+/**
+ * Assign an issue to a user
+ * @param key string - The key of the issue
+ * @param user string - The name or email of the user, "me" for the authenticated user
+ * @return error - An error if the user does not exist or if Jira fails
+ */
+func (j Client) AssignIssue(key string, user string) error {
+	var assignee *jira.User
+	if strings.EqualFold(user, "me") {
+		self, resp, err := j.client.User.GetSelf()
+		if err != nil {
+			return wrapError(resp, err)
+		}
+		assignee = self
+	} else {
+		users, resp, err := j.client.User.Find(user, jira.WithMaxResults(1))
+		if err != nil {
+			return wrapError(resp, err)
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("no user matches %q", user)
+		}
+		assignee = &users[0]
+	}
+	resp, err := j.client.Issue.UpdateAssignee(key, assignee)
+	return wrapError(resp, err)
+}
+
+// wrapError marks the errors of the requests that got no response as ErrOffline
+// and replaces the errors answered by Jira with their messages
+func wrapError(resp *jira.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+	if resp == nil {
+		return fmt.Errorf("%w: %w", ErrOffline, err)
+	}
+	var jiraErr *jira.Error
+	if errors.As(err, &jiraErr) {
+		fields := []string{}
+		for field, message := range jiraErr.Errors {
+			fields = append(fields, field+": "+message)
+		}
+		slices.Sort(fields)
+		messages := append(slices.Clone(jiraErr.ErrorMessages), fields...)
+		if len(messages) > 0 {
+			return &ResponseError{Status: resp.StatusCode, Messages: messages}
+		}
+	}
+	return err
+}
+
+// ResponseError is an error answered by Jira, such as an invalid query or a missing issue
+type ResponseError struct {
+	Status   int      // The HTTP status of the response
+	Messages []string // The messages of the response, readable by the user
+}
+
+func (e *ResponseError) Error() string {
+	return strings.Join(e.Messages, "; ")
 }
@@ -0,0 +1,267 @@
+package jira
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// The endpoints of the Atlassian OAuth 2.0 (3LO) authorization server
+var atlassianEndpoint = oauth2.Endpoint{
+	AuthURL:  "https://auth.atlassian.com/authorize",
+	TokenURL: "https://auth.atlassian.com/oauth/token",
+}
+
+const (
+	accessibleResourcesURL = "https://api.atlassian.com/oauth/token/accessible-resources"
+	cloudAPIURL            = "https://api.atlassian.com/ex/jira/%s/"
+)
+
+// How long the user has to authorize the application, and the site lookup to answer
+const (
+	authorizeTimeout = 5 * time.Minute
+	lookupTimeout    = 30 * time.Second
+)
+
+// The scopes requested when none are configured, offline_access is needed for the refresh token
+var defaultScopes = []string{"read:jira-work", "write:jira-work", "read:jira-user", "offline_access"}
+
+// OAuth authenticates with OAuth 2.0 (3LO). The first time the user is asked
+// to authorize the application in the browser, the code is received on a
+// loopback redirect. The token is then saved and refreshed when it expires.
+type OAuth struct {
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectPort int        // The port of the loopback redirect, it must match the app settings
+	Tokens       TokenStore // Where the token is saved between runs
+	// Prompt shows the authorization URL to the user, it prints to stderr if nil
+	Prompt func(url string)
+	// Context cancels the authorization and the lookup of the site, context.Background() if nil
+	Context context.Context
+	// NoAuthorize fails when no token is saved instead of asking the user, for the commands run unattended
+	NoAuthorize bool
+}
+
+// A TokenStore keeps the OAuth token between runs
+type TokenStore interface {
+	// Load returns the saved token or an error if there is none
+	Load() (*oauth2.Token, error)
+	Save(token *oauth2.Token) error
+}
+
+func (a OAuth) Authenticate(siteURL string) (*http.Client, string, error) {
+	cfg := &oauth2.Config{
+		ClientID:     a.ClientID,
+		ClientSecret: a.ClientSecret,
+		Endpoint:     atlassianEndpoint,
+		Scopes:       a.Scopes,
+		RedirectURL:  fmt.Sprintf("http://localhost:%d/callback", a.RedirectPort),
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+
+	// The OAuth library sends its requests with the client of the context, the
+	// context of the refreshes outlives the one of the connection
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: wireTransport})
+	token, err := a.Tokens.Load()
+	if err != nil && a.NoAuthorize {
+		return nil, "", fmt.Errorf("the application is not authorized, run `jiratui login`: %w", err)
+	} else if err != nil {
+		token, err = a.authorize(cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := a.Tokens.Save(token); err != nil {
+			return nil, "", err
+		}
+	}
+
+	source := &savingTokenSource{
+		source: cfg.TokenSource(ctx, token),
+		store:  a.Tokens,
+		last:   token,
+	}
+	client := oauth2.NewClient(ctx, source)
+
+	lookup, cancel := context.WithTimeout(a.context(), lookupTimeout)
+	defer cancel()
+	cloudID, err := findCloudID(lookup, client, siteURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, fmt.Sprintf(cloudAPIURL, cloudID), nil
+}
+
+// context returns the context of the connection
+func (a OAuth) context() context.Context {
+	if a.Context == nil {
+		return context.Background()
+	}
+	return a.Context
+}
+
+/**
+ * authorize runs the authorization code flow with a loopback redirect, the
+ * user has authorizeTimeout to grant the access unless the context ends first
+ * @param cfg *oauth2.Config - The OAuth client configuration
+ * @return *oauth2.Token - The token granted by the user
+ * @return error - The error encountered during the flow, if any
+ */
+func (a OAuth) authorize(cfg *oauth2.Config) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(a.context(), authorizeTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: wireTransport})
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", a.RedirectPort))
+	if err != nil {
+		return nil, fmt.Errorf("starting the OAuth redirect listener: %w", err)
+	}
+	defer listener.Close()
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	// Only the first answer counts, the handler must not block the shutdown of the server
+	send := func(res result) {
+		select {
+		case results <- res:
+		default:
+		}
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		switch {
+		case query.Get("state") != state:
+			http.Error(w, "Invalid state, please try again.", http.StatusBadRequest)
+			send(result{err: errors.New("OAuth state mismatch")})
+		case query.Get("error") != "":
+			http.Error(w, "Authorization denied, you can close this window.", http.StatusForbidden)
+			send(result{err: fmt.Errorf("OAuth authorization denied: %s", query.Get("error_description"))})
+		default:
+			fmt.Fprintln(w, "Authorization complete, you can close this window.")
+			send(result{code: query.Get("code")})
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	// audience and prompt are required by the Atlassian authorization server
+	url := cfg.AuthCodeURL(
+		state,
+		oauth2.SetAuthURLParam("audience", "api.atlassian.com"),
+		oauth2.SetAuthURLParam("prompt", "consent"),
+	)
+	if a.Prompt != nil {
+		a.Prompt(url)
+	} else {
+		fmt.Fprintf(os.Stderr, "Open the following URL to authorize JiraTUI:\n\n%s\n\n", url)
+	}
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("the authorization was not granted within %s", authorizeTimeout)
+		}
+		return nil, fmt.Errorf("authorization stopped: %w", ctx.Err())
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	return cfg.Exchange(ctx, res.code)
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+/**
+ * findCloudID looks up the id of the site among the resources the token grants access to
+ * @param ctx context.Context - The context of the request
+ * @param client *http.Client - The authenticated client
+ * @param siteURL string - The URL of the Jira site
+ * @return string - The cloud id of the site
+ * @return error - An error if the site is not accessible with the token
+ */
+func findCloudID(ctx context.Context, client *http.Client, siteURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, accessibleResourcesURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("listing the accessible resources: %s", resp.Status)
+	}
+
+	var resources []struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&resources); err != nil {
+		return "", err
+	}
+	for _, r := range resources {
+		if strings.TrimSuffix(r.URL, "/") == strings.TrimSuffix(siteURL, "/") {
+			return r.ID, nil
+		}
+	}
+	return "", fmt.Errorf("the token does not grant access to %s", siteURL)
+}
+
+// savingTokenSource saves the token every time it is refreshed, Atlassian
+// rotates the refresh tokens so the old one stops working
+type savingTokenSource struct {
+	mu     sync.Mutex
+	source oauth2.TokenSource
+	store  TokenStore
+	last   *oauth2.Token
+}
+
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token.AccessToken != s.last.AccessToken {
+		s.last = token
+		if err := s.store.Save(token); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
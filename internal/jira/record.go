@@ -0,0 +1,473 @@
+package jira
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	jira "github.com/andygrunwald/go-jira"
+)
+
+// An Interaction is a request and its response, one per line of a fixture file
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"` // The path and the query, relative to the base URL of the client
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// The response headers kept in the fixtures, the others may hold session cookies
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// The placeholders of the redacted values
+const (
+	redactedHost  = "jira.example.invalid"
+	redactedEmail = "redacted@example.invalid"
+)
+
+// The addresses in the query strings are escaped, %40 is the @
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+(@|%40)[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// The base URL of the clients replaying a fixture, requests never leave the process
+const replayBaseURL = "https://" + redactedHost + "/"
+
+// The fields naming a user, replaced in the user objects of the responses
+var identityFields = []string{"displayName", "accountId", "name", "key"}
+
+// The identity fields also replaced inside the longer values, such as the
+// mentions of a comment. The user names of Jira Server are often common words.
+var textIdentityFields = []string{"displayName", "accountId"}
+
+// The identities shorter than this are only replaced where they are a whole
+// value, inside a longer text they would match unrelated words
+const minIdentityLength = 4
+
+// The avatar of every redacted user, the avatar URLs hold a hash of the email
+const redactedAvatar = "https://" + redactedHost + "/avatar.png"
+
+/**
+ * redact removes the email addresses from a recorded text
+ * @param text string - The text to redact
+ * @return string - The redacted text
+ */
+func redact(text string) string {
+	return emailPattern.ReplaceAllString(text, redactedEmail)
+}
+
+// redactor removes what identifies the user or the instance from the recorded
+// interactions: the email addresses, the host names of the site and the names
+// of the users. The credentials are never recorded since the request headers
+// are not saved.
+type redactor struct {
+	hosts      []string          // Replaced with redactedHost, the longest first
+	identities map[string]string // The names of the users seen so far and their placeholders
+	inText     map[string]bool   // The identities also replaced inside the longer values
+	names      *regexp.Regexp    // Matches these identities as words of a value, nil until rebuilt
+}
+
+/**
+ * newRedactor creates a redactor hiding the given hosts
+ * @param hosts ...string - The host names of the site, with the path of the base URL if any
+ * @return *redactor - The redactor
+ */
+func newRedactor(hosts ...string) *redactor {
+	r := &redactor{identities: map[string]string{}, inText: map[string]bool{}}
+	for _, host := range hosts {
+		host = strings.Trim(host, "/")
+		if host != "" && !slices.Contains(r.hosts, host) {
+			r.hosts = append(r.hosts, host)
+		}
+	}
+	slices.SortFunc(r.hosts, func(a, b string) int { return len(b) - len(a) })
+	return r
+}
+
+/**
+ * text redacts a raw text: the emails and the hosts. The names of the users
+ * are only replaced in the values, a name may be any word of the text.
+ * @param text string - The text to redact
+ * @return string - The redacted text
+ */
+func (r *redactor) text(text string) string {
+	text = redact(text)
+	for _, host := range r.hosts {
+		text = strings.ReplaceAll(text, host, redactedHost)
+	}
+	return text
+}
+
+/**
+ * url redacts the path and the query of a request, the names of the users
+ * already seen are replaced in the values of the query
+ * @param u string - The path and the query, as returned by relativeURL
+ * @return string - The redacted URL
+ */
+func (r *redactor) url(u string) string {
+	path, query, ok := strings.Cut(r.text(u), "?")
+	if !ok {
+		return path
+	}
+	// The order of the parameters is kept, the replay matches the URL as sent
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+		if redacted := r.value(decoded); redacted != decoded {
+			params[i] = name + "=" + url.QueryEscape(redacted)
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+/**
+ * body redacts the body of a response. The names and the avatars of the users
+ * of a JSON body are replaced, then their names in the other string values,
+ * before redacting it as a text.
+ * @param body string - The body of the response
+ * @return string - The redacted body
+ */
+func (r *redactor) body(body string) string {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return r.text(body)
+	}
+	r.redactUsers(value)
+	value = r.redactValues(value)
+	var out strings.Builder
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return r.text(body)
+	}
+	return r.text(strings.TrimSuffix(out.String(), "\n"))
+}
+
+// redactUsers replaces the identity fields and the avatars of the user objects found in a JSON value
+func (r *redactor) redactUsers(value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		_, hasAccount := v["accountId"]
+		_, hasDisplayName := v["displayName"]
+		if hasAccount || hasDisplayName {
+			for _, field := range identityFields {
+				if name, ok := v[field].(string); ok && name != "" {
+					v[field] = r.placeholder(field, name)
+				}
+			}
+			if avatars, ok := v["avatarUrls"].(map[string]any); ok {
+				for size := range avatars {
+					avatars[size] = redactedAvatar
+				}
+			}
+		}
+		// In order, so that recording the same session gives the same placeholders
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			r.redactUsers(v[key])
+		}
+	case []any:
+		for _, child := range v {
+			r.redactUsers(child)
+		}
+	}
+}
+
+// redactValues replaces the names of the users in the string values of a JSON value, never in the keys
+func (r *redactor) redactValues(value any) any {
+	switch v := value.(type) {
+	case string:
+		return r.value(v)
+	case map[string]any:
+		for key, child := range v {
+			v[key] = r.redactValues(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = r.redactValues(child)
+		}
+	}
+	return value
+}
+
+/**
+ * value replaces the names of the users already seen in a value: the whole
+ * value, or the display names and the account ids found as words of it
+ * @param value string - A string value of a body or of a query
+ * @return string - The redacted value
+ */
+func (r *redactor) value(value string) string {
+	if placeholder, ok := r.identities[value]; ok {
+		return placeholder
+	}
+	if r.names == nil {
+		names := []string{}
+		for name := range r.inText {
+			if len(name) >= minIdentityLength {
+				names = append(names, regexp.QuoteMeta(name))
+			}
+		}
+		if len(names) == 0 {
+			return value
+		}
+		// The longest first, a name may contain another one
+		slices.SortFunc(names, func(a, b string) int { return len(b) - len(a) })
+		r.names = regexp.MustCompile(`\b(?:` + strings.Join(names, "|") + `)\b`)
+	}
+	return r.names.ReplaceAllStringFunc(value, func(name string) string {
+		return r.identities[name]
+	})
+}
+
+// placeholder returns the replacement of a name, the same name always gets the same one
+func (r *redactor) placeholder(field string, name string) string {
+	if placeholder, ok := r.identities[name]; ok {
+		return placeholder
+	}
+	placeholder := fmt.Sprintf("redacted-%s-%d", field, len(r.identities)+1)
+	r.identities[name] = placeholder
+	if slices.Contains(textIdentityFields, field) {
+		r.inText[name] = true
+		r.names = nil
+	}
+	return placeholder
+}
+
+// Option changes how NewClient builds the client
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	recordPath string
+}
+
+/**
+ * WithRecording saves every request and its response to a fixture file,
+ * redacted, so that the session can be replayed with NewReplayClient
+ * @param path string - The path of the fixture file, the interactions are appended
+ * @return Option - The option to pass to NewClient
+ */
+func WithRecording(path string) Option {
+	return func(o *clientOptions) {
+		o.recordPath = path
+	}
+}
+
+// recorder appends the interactions sent through it to a fixture file
+type recorder struct {
+	base     http.RoundTripper
+	baseURL  *url.URL
+	mu       sync.Mutex
+	file     *os.File
+	redactor *redactor
+}
+
+/**
+ * newRecorder opens the fixture file of a client
+ * @param base http.RoundTripper - The transport sending the requests
+ * @param baseURL string - The base URL of the REST API, api.atlassian.com for OAuth
+ * @param siteURL string - The URL of the site in the profile
+ * @param path string - The path of the fixture file
+ * @return *recorder - The recording transport
+ * @return error - The error encountered while opening the file, if any
+ */
+func newRecorder(base http.RoundTripper, baseURL string, siteURL string, path string) (*recorder, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	site, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	// The path of the OAuth base URL holds the cloud id of the site
+	redactor := newRedactor(u.Host+u.Path, u.Host, site.Host)
+	return &recorder{base: base, baseURL: u, file: file, redactor: redactor}, nil
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		// Nothing to replay, the replay answers the missing requests with an error too
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(strings.NewReader(string(body)))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interaction := Interaction{
+		Method: req.Method,
+		URL:    r.redactor.url(relativeURL(r.baseURL, req.URL)),
+		Status: resp.StatusCode,
+		Header: http.Header{},
+		Body:   r.redactor.body(string(body)),
+	}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			interaction.Header.Set(name, value)
+		}
+	}
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return resp, nil
+	}
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("recording the response: %w", err)
+	}
+	return resp, nil
+}
+
+// relativeURL returns the path and the query of the request, without the path of the base URL
+func relativeURL(base *url.URL, u *url.URL) string {
+	path := strings.TrimPrefix(u.Path, strings.TrimSuffix(base.Path, "/"))
+	path = strings.TrimPrefix(path, "/")
+	if u.RawQuery != "" {
+		return path + "?" + u.RawQuery
+	}
+	return path
+}
+
+// replayer answers the requests with the responses of a fixture file, in the recorded order
+type replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction // By method and URL
+}
+
+/**
+ * LoadInteractions reads a fixture file recorded with WithRecording
+ * @param path string - The path of the fixture file
+ * @return []Interaction - The interactions in the recorded order
+ * @return error - The error encountered while reading the fixture, if any
+ */
+func LoadInteractions(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	interactions := []Interaction{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var i Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		interactions = append(interactions, i)
+	}
+	return interactions, scanner.Err()
+}
+
+/**
+ * SearchQueries returns the JQL queries searched during the recorded session
+ * @param interactions []Interaction - The recorded interactions
+ * @return []string - The queries in order of first use, without duplicates
+ */
+func SearchQueries(interactions []Interaction) []string {
+	queries := []string{}
+	for _, i := range interactions {
+		path, query, _ := strings.Cut(i.URL, "?")
+		if path != "rest/api/2/search" {
+			continue
+		}
+		values, err := url.ParseQuery(query)
+		if jql := values.Get("jql"); err == nil && jql != "" && !slices.Contains(queries, jql) {
+			queries = append(queries, jql)
+		}
+	}
+	return queries
+}
+
+/**
+ * NewReplayClient creates a client answered from recorded interactions,
+ * without any network access. Identical requests get the recorded responses
+ * in order, the last one is repeated once they run out. Requests that were
+ * not recorded fail as if Jira was not reachable.
+ * @param interactions []Interaction - The interactions read by LoadInteractions
+ * @return *Client - The client replaying the interactions
+ * @return error - The error encountered while creating the client, if any
+ */
+func NewReplayClient(interactions []Interaction) (*Client, error) {
+	r := &replayer{interactions: map[string][]Interaction{}}
+	for _, i := range interactions {
+		key := i.Method + " " + i.URL
+		r.interactions[key] = append(r.interactions[key], i)
+	}
+
+	// The recorded responses are final, the retries were not recorded
+	transport := NewRetryTransport(r)
+	transport.MaxRetries = 0
+	client, err := jira.NewClient(&http.Client{Transport: transport}, replayBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client, transport}, nil
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	base, _ := url.Parse(replayBaseURL)
+	key := req.Method + " " + redact(relativeURL(base, req.URL))
+
+	r.mu.Lock()
+	queue := r.interactions[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s", key)
+	}
+	i := queue[0]
+	if len(queue) > 1 {
+		r.interactions[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	header := i.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(i.Body)),
+		ContentLength: int64(len(i.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package jira
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/logging"
+)
+
+// wireTransport is the base transport of every authentication method, it
+// sees the requests as sent, credentials included, and traces them
+var wireTransport http.RoundTripper = traceTransport{base: http.DefaultTransport}
+
+// traceTransport logs the requests and their responses at the debug level
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return t.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+		slog.Duration("duration", time.Since(start)),
+		headerGroup("request_headers", req.Header),
+	}
+	if err != nil {
+		slog.DebugContext(ctx, "http request failed", append(attrs, slog.Any("error", err))...)
+		return resp, err
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode), headerGroup("response_headers", resp.Header))
+	slog.DebugContext(ctx, "http request", attrs...)
+	return resp, err
+}
+
+// headerGroup returns the headers as a log group, hiding the credentials and the cookies
+func headerGroup(name string, header http.Header) slog.Attr {
+	attrs := []any{}
+	for key, values := range header {
+		value := values[0]
+		if logging.IsSecret(key) {
+			value = logging.Redacted
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return slog.Group(name, attrs...)
+}
+
+// redactURL hides the query parameters that may hold a secret, as the OAuth code exchange does
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	for key := range query {
+		if logging.IsSecret(key) || key == "code" {
+			query.Set(key, logging.Redacted)
+		}
+	}
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
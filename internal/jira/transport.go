@@ -0,0 +1,235 @@
+package jira
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The defaults of the retry transport, Atlassian asks clients to back off
+// exponentially and to honour Retry-After
+const (
+	DefaultMaxRetries    = 4
+	DefaultBaseDelay     = 500 * time.Millisecond
+	DefaultMaxDelay      = 30 * time.Second
+	DefaultMaxRetryAfter = 5 * time.Minute
+	DefaultMaxPerHost    = 4
+)
+
+// RetryState describes the requests waiting to be sent again, for the UI
+type RetryState struct {
+	Waiting int       // The number of requests waiting to be retried
+	Until   time.Time // When the last of them is retried
+	Status  int       // The status of the last response retried, 0 for a network error
+	Retries int       // The number of retries since the client was created
+}
+
+// RateLimited reports whether the requests wait because of the rate limit
+func (s RetryState) RateLimited() bool {
+	return s.Waiting > 0 && s.Status == http.StatusTooManyRequests
+}
+
+// RetryTransport retries the requests rejected by the rate limit and the
+// idempotent requests that failed with a temporary error, and caps the
+// number of requests sent to the same host at once
+type RetryTransport struct {
+	Base          http.RoundTripper
+	MaxRetries    int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration // Longer Retry-After are not waited, the response is returned
+	MaxPerHost    int
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+	state RetryState
+}
+
+/**
+ * NewRetryTransport wraps a transport with the default retry policy
+ * @param base http.RoundTripper - The transport sending the requests, http.DefaultTransport if nil
+ * @return *RetryTransport - The retrying transport
+ */
+func NewRetryTransport(base http.RoundTripper) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{
+		Base:          base,
+		MaxRetries:    DefaultMaxRetries,
+		BaseDelay:     DefaultBaseDelay,
+		MaxDelay:      DefaultMaxDelay,
+		MaxRetryAfter: DefaultMaxRetryAfter,
+		MaxPerHost:    DefaultMaxPerHost,
+		hosts:         map[string]chan struct{}{},
+	}
+}
+
+/**
+ * State returns the requests currently waiting to be retried
+ * @return RetryState - A copy of the retry state
+ */
+func (t *RetryTransport) State() RetryState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil && req.GetBody != nil {
+			// The body was consumed by the previous attempt
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.send(req)
+		delay, retry := t.shouldRetry(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain the body so that the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		if err := t.wait(req, delay, status); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send waits for a free slot of the host. The slot is released once the
+// response headers are received: go-jira does not always close the body.
+func (t *RetryTransport) send(req *http.Request) (*http.Response, error) {
+	slots := t.slots(req.URL.Host)
+	select {
+	case slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-slots }()
+	return t.Base.RoundTrip(req)
+}
+
+func (t *RetryTransport) slots(host string) chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	slots, ok := t.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, max(t.MaxPerHost, 1))
+		t.hosts[host] = slots
+	}
+	return slots
+}
+
+// shouldRetry decides whether the request is sent again and after how long
+func (t *RetryTransport) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.MaxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	// A body that cannot be read again cannot be sent again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 0, false
+	}
+	if err != nil {
+		// Without a connection there is nothing to wait for, the cache takes over
+		return t.backoff(attempt), isIdempotent(req) && !isDialError(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		// The request was rejected before being processed, any method can be sent again
+		if after, ok := retryAfter(resp); ok {
+			return after, after <= t.MaxRetryAfter
+		}
+		return t.backoff(attempt), true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if after, ok := retryAfter(resp); ok {
+			return after, isIdempotent(req) && after <= t.MaxRetryAfter
+		}
+		return t.backoff(attempt), isIdempotent(req)
+	}
+	return 0, false
+}
+
+// backoff returns the jittered exponential delay of the attempt, between half and all of it
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	delay := t.BaseDelay << attempt
+	if delay <= 0 || delay > t.MaxDelay {
+		delay = t.MaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// wait sleeps before the next attempt, recording it in the retry state
+func (t *RetryTransport) wait(req *http.Request, delay time.Duration, status int) error {
+	until := time.Now().Add(delay)
+	t.mu.Lock()
+	t.state.Waiting++
+	t.state.Retries++
+	t.state.Status = status
+	if until.After(t.state.Until) {
+		t.state.Until = until
+	}
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.state.Waiting--
+		t.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// isIdempotent reports whether sending the request twice has the same effect as sending it once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// isDialError reports whether the request failed before reaching the server
+func isDialError(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || (errors.As(err, &opErr) && opErr.Op == "dial")
+}
+
+// retryAfter parses the Retry-After header, given in seconds or as a date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,119 @@
+package jiratest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Failure changes the answer of the server to the matching requests
+type Failure struct {
+	Method     string        // The method of the requests to fail, empty for every method
+	Path       string        // The prefix of the paths to fail, empty for every path
+	Latency    time.Duration // The delay before answering
+	Status     int           // The status to answer with, 0 to answer normally after the latency
+	RetryAfter time.Duration // The Retry-After header of the answer, 0 to omit it
+	Drop       bool          // Close the connection without answering, as a network failure
+	Times      int           // The number of requests to fail, 0 for every request
+
+	hits int
+}
+
+/**
+ * Inject adds a failure. The first matching failure answering in place of the
+ * server is applied to a request, after the latencies of the matching delays.
+ * @param f Failure - The failure to inject
+ */
+func (s *Server) Inject(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &f)
+}
+
+/**
+ * RateLimit answers 429 Too Many Requests to the next requests
+ * @param times int - The number of requests to reject, 0 for every request
+ * @param retryAfter time.Duration - The Retry-After sent to the client, 0 to omit it
+ */
+func (s *Server) RateLimit(times int, retryAfter time.Duration) {
+	s.Inject(Failure{Status: http.StatusTooManyRequests, RetryAfter: retryAfter, Times: times})
+}
+
+/**
+ * Delay slows down every answer, to test the spinners and the timeouts
+ * @param latency time.Duration - The delay before answering
+ */
+func (s *Server) Delay(latency time.Duration) {
+	s.Inject(Failure{Latency: latency})
+}
+
+// ClearFailures removes the injected failures
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = nil
+}
+
+// failure returns the failure to apply to the request, counting the hits
+func (s *Server) failure(r *http.Request) *Failure {
+	var latency time.Duration
+	for _, f := range s.failures {
+		if f.Times > 0 && f.hits >= f.Times {
+			continue
+		}
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+		f.hits++
+		if f.Status == 0 && !f.Drop {
+			// A delay only, the failures injected after it still apply
+			latency += f.Latency
+			continue
+		}
+		// A copy, the failure may be changed by the next request
+		failure := *f
+		failure.Latency += latency
+		return &failure
+	}
+	if latency > 0 {
+		return &Failure{Latency: latency}
+	}
+	return nil
+}
+
+// apply waits for the latency and answers in place of the server
+// @return bool - True if the request was answered
+func (f *Failure) apply(w http.ResponseWriter, r *http.Request) bool {
+	if f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	if f.Drop {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+	if f.Status == 0 {
+		return false
+	}
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((f.RetryAfter+time.Second-1)/time.Second)))
+	}
+	message := http.StatusText(f.Status)
+	if f.Status == http.StatusTooManyRequests {
+		message = "Rate limit exceeded."
+	}
+	writeError(w, f.Status, message)
+	return true
+}
@@ -0,0 +1,120 @@
+package jiratest
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures is the content of the fake instance, usually read from a YAML file
+type Fixtures struct {
+	Self     string              `yaml:"self"`     // The account id of the authenticated user
+	Users    []User              `yaml:"users"`    // The users of the instance
+	Workflow map[string][]string `yaml:"workflow"` // The statuses reachable from every status
+	Issues   []Issue             `yaml:"issues"`
+}
+
+type User struct {
+	AccountID   string `yaml:"account_id"`
+	DisplayName string `yaml:"display_name"`
+	Email       string `yaml:"email"`
+}
+
+type Issue struct {
+	Key         string    `yaml:"key"`
+	Summary     string    `yaml:"summary"`
+	Description string    `yaml:"description,omitempty"`
+	Type        string    `yaml:"type,omitempty"`
+	Status      string    `yaml:"status"`
+	Priority    string    `yaml:"priority,omitempty"`
+	Resolution  string    `yaml:"resolution,omitempty"`
+	Assignee    string    `yaml:"assignee,omitempty"` // The account id of the assignee
+	Reporter    string    `yaml:"reporter,omitempty"` // The account id of the reporter
+	Labels      []string  `yaml:"labels,omitempty"`
+	Created     time.Time `yaml:"created,omitempty"`
+	Updated     time.Time `yaml:"updated,omitempty"`
+	Comments    []Comment `yaml:"comments,omitempty"`
+}
+
+type Comment struct {
+	ID      string    `yaml:"id"`
+	Author  string    `yaml:"author"` // The account id of the author
+	Body    string    `yaml:"body"`
+	Created time.Time `yaml:"created"`
+}
+
+// The workflow used when the fixtures do not define one
+var defaultWorkflow = map[string][]string{
+	"To Do":       {"In Progress", "Done"},
+	"In Progress": {"To Do", "Done"},
+	"Done":        {"To Do"},
+}
+
+//go:embed fixtures/default.yaml
+var defaultFixtures []byte
+
+/**
+ * DefaultFixtures returns a small project with a few users, issues and comments
+ * @return Fixtures - The fixtures embedded in the package
+ */
+func DefaultFixtures() Fixtures {
+	f, err := ParseFixtures(defaultFixtures)
+	if err != nil {
+		panic(fmt.Sprintf("jiratest: invalid default fixtures: %s", err))
+	}
+	return f
+}
+
+/**
+ * LoadFixtures reads the fixtures from a YAML file
+ * @param path string - The path of the fixture file
+ * @return Fixtures - The fixtures
+ * @return error - The error encountered while reading or parsing the file, if any
+ */
+func LoadFixtures(path string) (Fixtures, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Fixtures{}, err
+	}
+	f, err := ParseFixtures(content)
+	if err != nil {
+		return Fixtures{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return f, nil
+}
+
+/**
+ * ParseFixtures decodes fixtures written in YAML, or in JSON
+ * @param content []byte - The content of a fixture file
+ * @return Fixtures - The fixtures
+ * @return error - An error if the content is malformed or inconsistent
+ */
+func ParseFixtures(content []byte) (Fixtures, error) {
+	var f Fixtures
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return Fixtures{}, err
+	}
+	users := map[string]bool{}
+	for _, u := range f.Users {
+		users[u.AccountID] = true
+	}
+	if f.Self != "" && !users[f.Self] {
+		return Fixtures{}, fmt.Errorf("the authenticated user %q is not a user", f.Self)
+	}
+	keys := map[string]bool{}
+	for _, issue := range f.Issues {
+		if issue.Key == "" || keys[issue.Key] {
+			return Fixtures{}, fmt.Errorf("the issue key %q is missing or duplicated", issue.Key)
+		}
+		keys[issue.Key] = true
+		for _, id := range []string{issue.Assignee, issue.Reporter} {
+			if id != "" && !users[id] {
+				return Fixtures{}, fmt.Errorf("issue %s: unknown user %q", issue.Key, id)
+			}
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,411 @@
+package jiratest
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jql"
+)
+
+// A predicate tells whether an issue matches a part of the query
+type predicate func(issue *Issue) bool
+
+// A query is a JQL query compiled against the issues of the server
+type query struct {
+	match predicate
+	order []sortKey
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// compiler turns the tokens of a query into predicates. The syntax is checked
+// by jql.Validate beforehand, the compiler only rejects what the fake server
+// does not implement.
+type compiler struct {
+	server *Server
+	tokens []jql.Token
+	pos    int
+}
+
+// The fields understood by the fake server, with their aliases
+var fieldNames = map[string]string{
+	"key":         "key",
+	"issuekey":    "key",
+	"id":          "key",
+	"project":     "project",
+	"status":      "status",
+	"assignee":    "assignee",
+	"reporter":    "reporter",
+	"summary":     "summary",
+	"description": "description",
+	"text":        "text",
+	"type":        "type",
+	"issuetype":   "type",
+	"priority":    "priority",
+	"resolution":  "resolution",
+	"labels":      "labels",
+	"created":     "created",
+	"updated":     "updated",
+}
+
+/**
+ * compile parses a query of the supported JQL subset
+ * @param s *Server - The server, used to resolve users and currentUser()
+ * @param text string - The JQL query
+ * @return query - The compiled query
+ * @return error - A syntax error or an unsupported construct, answered with a 400
+ */
+func compile(s *Server, text string) (query, error) {
+	if err := jql.Validate(text); err != nil {
+		return query{}, fmt.Errorf("Error in the JQL Query: %s", err.Msg)
+	}
+	c := compiler{server: s, tokens: jql.Tokenize(text)}
+	q := query{match: func(*Issue) bool { return true }}
+	if c.peek() != nil && !c.isWord("ORDER") {
+		match, err := c.or()
+		if err != nil {
+			return query{}, err
+		}
+		q.match = match
+	}
+	if c.accept("ORDER") {
+		c.accept("BY")
+		for {
+			t := c.next()
+			field, ok := fieldNames[strings.ToLower(unquote(t.Text))]
+			if !ok {
+				return query{}, unknownField(t.Text)
+			}
+			key := sortKey{field: field}
+			if c.accept("DESC") {
+				key.desc = true
+			} else {
+				c.accept("ASC")
+			}
+			q.order = append(q.order, key)
+			if !c.accept(",") {
+				break
+			}
+		}
+	}
+	return q, nil
+}
+
+func unknownField(name string) error {
+	return fmt.Errorf("Field '%s' does not exist or you do not have permission to view it.", unquote(name))
+}
+
+func (c *compiler) peek() *jql.Token {
+	if c.pos < len(c.tokens) {
+		return &c.tokens[c.pos]
+	}
+	return nil
+}
+
+func (c *compiler) next() jql.Token {
+	t := c.tokens[c.pos]
+	c.pos++
+	return t
+}
+
+func (c *compiler) isWord(word string) bool {
+	t := c.peek()
+	return t != nil && t.Kind != jql.String && strings.EqualFold(t.Text, word)
+}
+
+func (c *compiler) accept(word string) bool {
+	if c.isWord(word) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+func (c *compiler) or() (predicate, error) {
+	left, err := c.and()
+	for err == nil && c.accept("OR") {
+		var right predicate
+		right, err = c.and()
+		l := left
+		left = func(i *Issue) bool { return l(i) || right(i) }
+	}
+	return left, err
+}
+
+func (c *compiler) and() (predicate, error) {
+	left, err := c.not()
+	for err == nil && c.accept("AND") {
+		var right predicate
+		right, err = c.not()
+		l := left
+		left = func(i *Issue) bool { return l(i) && right(i) }
+	}
+	return left, err
+}
+
+func (c *compiler) not() (predicate, error) {
+	switch {
+	case c.accept("NOT"):
+		p, err := c.not()
+		return func(i *Issue) bool { return !p(i) }, err
+	case c.accept("("):
+		p, err := c.or()
+		c.accept(")")
+		return p, err
+	}
+	return c.clause()
+}
+
+func (c *compiler) clause() (predicate, error) {
+	name := c.next().Text
+	field, ok := fieldNames[strings.ToLower(unquote(name))]
+	if !ok {
+		return nil, unknownField(name)
+	}
+
+	switch {
+	case c.accept("IS"):
+		negate := c.accept("NOT")
+		c.pos++ // EMPTY or NULL
+		return func(i *Issue) bool {
+			return (len(c.server.values(i, field)) == 0) != negate
+		}, nil
+	case c.isWord("NOT") || c.isWord("IN"):
+		negate := c.accept("NOT")
+		c.accept("IN")
+		values, err := c.list()
+		if err != nil {
+			return nil, err
+		}
+		return func(i *Issue) bool {
+			actual := c.server.values(i, field)
+			found := slices.ContainsFunc(values, func(v string) bool { return c.server.equal(actual, v) })
+			// Like Jira, NOT IN does not match the issues without a value
+			return found != negate && (!negate || len(actual) > 0)
+		}, nil
+	case c.isWord("WAS") || c.isWord("CHANGED"):
+		return nil, fmt.Errorf("the %s operator is not supported by the fake server", strings.ToUpper(c.peek().Text))
+	}
+
+	op := c.next().Text
+	value, err := c.operand()
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "=":
+		return func(i *Issue) bool { return c.server.equal(c.server.values(i, field), value) }, nil
+	case "!=":
+		return func(i *Issue) bool {
+			actual := c.server.values(i, field)
+			return len(actual) > 0 && !c.server.equal(actual, value)
+		}, nil
+	case "~", "!~":
+		negate := op == "!~"
+		return func(i *Issue) bool { return contains(c.server.values(i, field), value) != negate }, nil
+	case "<", "<=", ">", ">=":
+		if field != "created" && field != "updated" {
+			return nil, fmt.Errorf("the %s operator is only supported on dates by the fake server", op)
+		}
+		limit, err := parseDate(value, c.server.now())
+		if err != nil {
+			return nil, err
+		}
+		return func(i *Issue) bool {
+			date := i.Updated
+			if field == "created" {
+				date = i.Created
+			}
+			switch op {
+			case "<":
+				return date.Before(limit)
+			case "<=":
+				return !date.After(limit)
+			case ">":
+				return date.After(limit)
+			}
+			return !date.Before(limit)
+		}, nil
+	}
+	return nil, fmt.Errorf("the %s operator is not supported by the fake server", op)
+}
+
+// operand returns the value compared with a field, resolving the supported functions
+func (c *compiler) operand() (string, error) {
+	t := c.next()
+	switch {
+	case t.Kind == jql.Function:
+		name := strings.ToLower(t.Text)
+		// Skip the arguments, none of the supported functions takes any
+		for c.peek() != nil && c.next().Text != ")" {
+		}
+		switch name {
+		case "currentuser":
+			return c.server.fixtures.Self, nil
+		case "now":
+			return c.server.now().Format("2006-01-02 15:04"), nil
+		}
+		return "", fmt.Errorf("Unable to find JQL function '%s()'.", t.Text)
+	case strings.EqualFold(t.Text, "EMPTY") || strings.EqualFold(t.Text, "NULL"):
+		return "", nil
+	}
+	return unquote(t.Text), nil
+}
+
+func (c *compiler) list() ([]string, error) {
+	values := []string{}
+	c.accept("(")
+	for c.peek() != nil && !c.accept(")") {
+		if c.accept(",") {
+			continue
+		}
+		value, err := c.operand()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func unquote(text string) string {
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') {
+		return strings.ReplaceAll(text[1:len(text)-1], `\`+text[:1], text[:1])
+	}
+	return text
+}
+
+// values returns the values of a field of the issue, empty if the field is not set.
+// Users are compared by account id, display name and email, they are all returned.
+func (s *Server) values(i *Issue, field string) []string {
+	single := func(v string) []string {
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	}
+	switch field {
+	case "key":
+		return single(i.Key)
+	case "project":
+		project, _, _ := strings.Cut(i.Key, "-")
+		return single(project)
+	case "status":
+		return single(i.Status)
+	case "assignee":
+		return s.userValues(i.Assignee)
+	case "reporter":
+		return s.userValues(i.Reporter)
+	case "summary":
+		return single(i.Summary)
+	case "description":
+		return single(i.Description)
+	case "text":
+		values := []string{i.Summary, i.Description}
+		for _, comment := range i.Comments {
+			values = append(values, comment.Body)
+		}
+		return values
+	case "type":
+		return single(i.Type)
+	case "priority":
+		return single(i.Priority)
+	case "resolution":
+		return single(i.Resolution)
+	case "labels":
+		return i.Labels
+	case "created":
+		return single(i.Created.Format(time.RFC3339))
+	case "updated":
+		return single(i.Updated.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (s *Server) userValues(accountID string) []string {
+	if u, ok := s.user(accountID); ok {
+		return []string{u.AccountID, u.DisplayName, u.Email}
+	}
+	return nil
+}
+
+// equal compares the values case insensitively, like Jira does for most fields
+func (s *Server) equal(actual []string, value string) bool {
+	if value == "" {
+		return len(actual) == 0
+	}
+	return slices.ContainsFunc(actual, func(v string) bool { return strings.EqualFold(v, value) })
+}
+
+// contains implements the text search operator, without Jira's stemming
+func contains(actual []string, value string) bool {
+	value = strings.ToLower(strings.Trim(value, "*"))
+	return slices.ContainsFunc(actual, func(v string) bool {
+		return strings.Contains(strings.ToLower(v), value)
+	})
+}
+
+var relativeDate = regexp.MustCompile(`^([-+]?\d+)([mhdw])$`)
+
+// parseDate reads the absolute and relative dates accepted by Jira, such as 2024-03-01 or -7d
+func parseDate(value string, now time.Time) (time.Time, error) {
+	if m := relativeDate.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[m[2]]
+		return now.Add(time.Duration(n) * unit), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006/01/02 15:04", "2006-01-02", "2006/01/02"} {
+		if date, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Date value '%s' for field is invalid.", value)
+}
+
+// sort orders the issues as requested by the ORDER BY clause, fixture order otherwise
+func (q query) sort(s *Server, issues []*Issue) {
+	sort.SliceStable(issues, func(a, b int) bool {
+		for _, key := range q.order {
+			cmp := compareField(s, issues[a], issues[b], key.field)
+			if key.desc {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+}
+
+func compareField(s *Server, a *Issue, b *Issue, field string) int {
+	switch field {
+	case "key":
+		pa, na := splitKey(a.Key)
+		pb, nb := splitKey(b.Key)
+		if pa != pb {
+			return strings.Compare(pa, pb)
+		}
+		return na - nb
+	case "created":
+		return a.Created.Compare(b.Created)
+	case "updated":
+		return a.Updated.Compare(b.Updated)
+	}
+	va, vb := s.values(a, field), s.values(b, field)
+	return strings.Compare(strings.Join(va, ","), strings.Join(vb, ","))
+}
+
+func splitKey(key string) (string, int) {
+	project, number, _ := strings.Cut(key, "-")
+	n, _ := strconv.Atoi(number)
+	return project, n
+}
@@ -0,0 +1,465 @@
+// Package jiratest provides a fake Jira REST server for integration tests.
+// It implements the endpoints used by internal/jira: search with a subset
+// of JQL, issues, comments, transitions and users. The content is seeded
+// from fixture files and failures can be injected to test the retries and
+// the offline mode.
+package jiratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// The page size of the search when the client does not ask for one, as in Jira Cloud
+const defaultMaxResults = 50
+
+type Server struct {
+	*httptest.Server
+	// Now returns the time of the changes, time.Now if nil
+	Now func() time.Time
+
+	mu        sync.Mutex
+	fixtures  Fixtures
+	issues    []*Issue
+	failures  []*Failure
+	requests  []string
+	commentID int
+}
+
+/**
+ * NewServer starts a fake Jira server seeded with the fixtures
+ * @param f Fixtures - The content of the instance
+ * @return *Server - The running server, to close once done
+ */
+func NewServer(f Fixtures) *Server {
+	s := &Server{}
+	s.Seed(f)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/2/search", s.search)
+	mux.HandleFunc("GET /rest/api/2/issue/{key}", s.getIssue)
+	mux.HandleFunc("GET /rest/api/2/issue/{key}/comment", s.getComments)
+	mux.HandleFunc("POST /rest/api/2/issue/{key}/comment", s.addComment)
+	mux.HandleFunc("GET /rest/api/2/issue/{key}/transitions", s.getTransitions)
+	mux.HandleFunc("POST /rest/api/2/issue/{key}/transitions", s.doTransition)
+	mux.HandleFunc("PUT /rest/api/2/issue/{key}/assignee", s.assign)
+	mux.HandleFunc("GET /rest/api/2/myself", s.myself)
+	mux.HandleFunc("GET /rest/api/2/user", s.getUser)
+	mux.HandleFunc("GET /rest/api/2/user/search", s.findUsers)
+	s.Server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+/**
+ * Seed replaces the content of the instance, the injected failures are kept
+ * @param f Fixtures - The new content
+ */
+func (s *Server) Seed(f Fixtures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Workflow == nil {
+		f.Workflow = defaultWorkflow
+	}
+	s.fixtures = f
+	s.issues = []*Issue{}
+	for _, issue := range f.Issues {
+		issue.Labels = slices.Clone(issue.Labels)
+		issue.Comments = slices.Clone(issue.Comments)
+		s.issues = append(s.issues, &issue)
+		for _, comment := range issue.Comments {
+			if id, err := strconv.Atoi(comment.ID); err == nil && id > s.commentID {
+				s.commentID = id
+			}
+		}
+	}
+}
+
+/**
+ * Client returns a client of the internal/jira package connected to the server
+ * @return *jira.Client - The client, authenticated as the fixtures' user
+ * @return error - The error encountered while creating the client, if any
+ */
+func (s *Server) Client() (*jira.Client, error) {
+	s.mu.Lock()
+	self, _ := s.user(s.fixtures.Self)
+	s.mu.Unlock()
+	return jira.NewClient(s.URL, jira.BasicAuth{Email: self.Email, Token: "jiratest"})
+}
+
+/**
+ * Issue returns the current state of an issue, to check the effect of a request
+ * @param key string - The key of the issue
+ * @return Issue - A copy of the issue
+ * @return bool - False if there is no such issue
+ */
+func (s *Server) Issue(key string) (Issue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if issue := s.issue(key); issue != nil {
+		return *issue, true
+	}
+	return Issue{}, false
+}
+
+/**
+ * Requests returns the requests received so far, as "METHOD /path?query"
+ * @return []string - The requests in order of arrival
+ */
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// middleware records the requests, checks the credentials and applies the injected failures
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+		failure := s.failure(r)
+		s.mu.Unlock()
+
+		if failure != nil && failure.apply(w, r) {
+			return
+		}
+		if r.Header.Get("Authorization") == "" {
+			writeError(w, http.StatusUnauthorized, "You are not authenticated. Authentication required to perform this operation.")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) issue(key string) *Issue {
+	for _, issue := range s.issues {
+		if strings.EqualFold(issue.Key, key) {
+			return issue
+		}
+	}
+	return nil
+}
+
+func (s *Server) user(accountID string) (User, bool) {
+	for _, u := range s.fixtures.Users {
+		if u.AccountID == accountID {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// findUser matches a user by account id, email or display name, as the user picker does
+func (s *Server) findUser(query string) []User {
+	users := []User{}
+	query = strings.ToLower(query)
+	for _, u := range s.fixtures.Users {
+		if u.AccountID == query || strings.Contains(strings.ToLower(u.Email), query) ||
+			strings.Contains(strings.ToLower(u.DisplayName), query) {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q, err := compile(s, r.URL.Query().Get("jql"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	matches := []*Issue{}
+	for _, issue := range s.issues {
+		if q.match(issue) {
+			matches = append(matches, issue)
+		}
+	}
+	q.sort(s, matches)
+
+	startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+	maxResults, err := strconv.Atoi(r.URL.Query().Get("maxResults"))
+	if err != nil || maxResults <= 0 {
+		maxResults = defaultMaxResults
+	}
+	page := []any{}
+	for i := startAt; i >= 0 && i < len(matches) && i < startAt+maxResults; i++ {
+		page = append(page, s.issueJSON(matches[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"startAt":    startAt,
+		"maxResults": maxResults,
+		"total":      len(matches),
+		"issues":     page,
+	})
+}
+
+func (s *Server) getIssue(w http.ResponseWriter, r *http.Request) {
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.issueJSON(issue))
+}
+
+func (s *Server) getComments(w http.ResponseWriter, r *http.Request) {
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.commentsJSON(issue))
+}
+
+func (s *Server) addComment(w http.ResponseWriter, r *http.Request) {
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	var body struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Body) == "" {
+		writeError(w, http.StatusBadRequest, "Comment body can not be empty!")
+		return
+	}
+	s.commentID++
+	comment := Comment{
+		ID:      strconv.Itoa(s.commentID),
+		Author:  s.fixtures.Self,
+		Body:    body.Body,
+		Created: s.now(),
+	}
+	issue.Comments = append(issue.Comments, comment)
+	issue.Updated = comment.Created
+	writeJSON(w, http.StatusCreated, s.commentJSON(comment))
+}
+
+// transitions returns the transitions available from the status of the issue,
+// the id of a transition is the position of its target status in the workflow
+func (s *Server) transitions(issue *Issue) []map[string]any {
+	statuses := s.statuses()
+	transitions := []map[string]any{}
+	for _, to := range s.fixtures.Workflow[issue.Status] {
+		id := strconv.Itoa(slices.Index(statuses, to) + 1)
+		transitions = append(transitions, map[string]any{
+			"id":   id,
+			"name": to,
+			"to":   map[string]any{"id": id, "name": to},
+		})
+	}
+	return transitions
+}
+
+// statuses returns every status of the workflow in a stable order
+func (s *Server) statuses() []string {
+	statuses := []string{}
+	for from, targets := range s.fixtures.Workflow {
+		statuses = append(statuses, from)
+		statuses = append(statuses, targets...)
+	}
+	slices.Sort(statuses)
+	return slices.Compact(statuses)
+}
+
+func (s *Server) getTransitions(w http.ResponseWriter, r *http.Request) {
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transitions": s.transitions(issue)})
+}
+
+func (s *Server) doTransition(w http.ResponseWriter, r *http.Request) {
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	var body struct {
+		Transition struct {
+			ID string `json:"id"`
+		} `json:"transition"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, t := range s.transitions(issue) {
+		if t["id"] == body.Transition.ID {
+			issue.Status = t["name"].(string)
+			issue.Updated = s.now()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusBadRequest, fmt.Sprintf("Transition id '%s' is not valid for this issue.", body.Transition.ID))
+}
+
+func (s *Server) assign(w http.ResponseWriter, r *http.Request) {
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	var body struct {
+		AccountID string `json:"accountId"`
+		Name      string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	id := body.AccountID
+	if id == "" && body.Name != "" {
+		if users := s.findUser(body.Name); len(users) > 0 {
+			id = users[0].AccountID
+		}
+	}
+	if _, ok := s.user(id); id != "" && !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("User '%s' cannot be assigned issues.", id))
+		return
+	}
+	issue.Assignee = id
+	issue.Updated = s.now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) myself(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.user(s.fixtures.Self)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Client must be authenticated to access this resource.")
+		return
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.user(r.URL.Query().Get("accountId"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "The user does not exist.")
+		return
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
+
+func (s *Server) findUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		query = r.URL.Query().Get("username")
+	}
+	users := []any{}
+	for _, u := range s.findUser(query) {
+		users = append(users, userJSON(u))
+	}
+	if max, err := strconv.Atoi(r.URL.Query().Get("maxResults")); err == nil && max < len(users) {
+		users = users[:max]
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+// issueJSON renders an issue the way the REST API v2 does, with the fields used by the clients
+func (s *Server) issueJSON(issue *Issue) map[string]any {
+	project, _, _ := strings.Cut(issue.Key, "-")
+	fields := map[string]any{
+		"summary":     issue.Summary,
+		"description": issue.Description,
+		"issuetype":   map[string]any{"name": issue.Type},
+		"project":     map[string]any{"key": project},
+		"status":      map[string]any{"name": issue.Status},
+		"labels":      append([]string{}, issue.Labels...),
+		"created":     formatTime(issue.Created),
+		"updated":     formatTime(issue.Updated),
+		"comment":     s.commentsJSON(issue),
+	}
+	if issue.Priority != "" {
+		fields["priority"] = map[string]any{"name": issue.Priority}
+	}
+	if issue.Resolution != "" {
+		fields["resolution"] = map[string]any{"name": issue.Resolution}
+	}
+	if u, ok := s.user(issue.Assignee); ok {
+		fields["assignee"] = userJSON(u)
+	}
+	if u, ok := s.user(issue.Reporter); ok {
+		fields["reporter"] = userJSON(u)
+	}
+	return map[string]any{
+		"id":     strconv.Itoa(slices.Index(s.issues, issue) + 10000),
+		"key":    issue.Key,
+		"self":   s.URL + "/rest/api/2/issue/" + issue.Key,
+		"fields": fields,
+	}
+}
+
+func (s *Server) commentsJSON(issue *Issue) map[string]any {
+	comments := []any{}
+	for _, comment := range issue.Comments {
+		comments = append(comments, s.commentJSON(comment))
+	}
+	return map[string]any{
+		"startAt":    0,
+		"maxResults": len(comments),
+		"total":      len(comments),
+		"comments":   comments,
+	}
+}
+
+func (s *Server) commentJSON(comment Comment) map[string]any {
+	c := map[string]any{
+		"id":      comment.ID,
+		"body":    comment.Body,
+		"created": formatTime(comment.Created),
+		"updated": formatTime(comment.Created),
+	}
+	if u, ok := s.user(comment.Author); ok {
+		c["author"] = userJSON(u)
+	}
+	return c
+}
+
+func userJSON(u User) map[string]any {
+	return map[string]any{
+		"accountId":    u.AccountID,
+		"displayName":  u.DisplayName,
+		"emailAddress": u.Email,
+		"active":       true,
+	}
+}
+
+// formatTime uses the date format of Jira, which is not RFC 3339
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.000-0700")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError answers with the error body of the REST API
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"errorMessages": []string{message},
+		"errors":        map[string]string{},
+	})
+}
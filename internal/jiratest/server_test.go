@@ -0,0 +1,203 @@
+package jiratest_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/jiratest"
+)
+
+// newServer starts a server seeded with the default fixtures and its client
+func newServer(t *testing.T) (*jiratest.Server, *jira.Client) {
+	t.Helper()
+	s := jiratest.NewServer(jiratest.DefaultFixtures())
+	t.Cleanup(s.Close)
+	client, err := s.Client()
+	if err != nil {
+		t.Fatalf("creating the client: %s", err)
+	}
+	return s, client
+}
+
+// countRequests returns the number of requests received starting with a prefix
+func countRequests(s *jiratest.Server, prefix string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if strings.HasPrefix(r, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSearch(t *testing.T) {
+	_, client := newServer(t)
+	issues, err := client.SearchIssues("project = PROJ AND status != Done ORDER BY key DESC")
+	if err != nil {
+		t.Fatalf("searching: %s", err)
+	}
+	keys := []string{}
+	for _, issue := range issues {
+		keys = append(keys, issue.Key)
+	}
+	if want := []string{"PROJ-2", "PROJ-1"}; !slices.Equal(keys, want) {
+		t.Errorf("got %v, want %v", keys, want)
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	f := jiratest.DefaultFixtures()
+	f.Issues = nil
+	total := jira.SearchPageSize + 20
+	for i := 1; i <= total; i++ {
+		f.Issues = append(f.Issues, jiratest.Issue{Key: fmt.Sprintf("BULK-%d", i), Summary: "Bulk", Status: "To Do"})
+	}
+	s := jiratest.NewServer(f)
+	defer s.Close()
+	client, err := s.Client()
+	if err != nil {
+		t.Fatalf("creating the client: %s", err)
+	}
+
+	page, count, err := client.SearchPage("project = BULK ORDER BY key", 10, 5)
+	if err != nil {
+		t.Fatalf("searching a page: %s", err)
+	}
+	if count != total || len(page) != 5 || page[0].Key != "BULK-11" {
+		t.Errorf("got %d issues from %v of %d, want 5 from BULK-11 of %d", len(page), page, count, total)
+	}
+
+	issues, err := client.SearchAll("project = BULK ORDER BY key", 0)
+	if err != nil {
+		t.Fatalf("searching all the pages: %s", err)
+	}
+	if len(issues) != total || issues[total-1].Key != fmt.Sprintf("BULK-%d", total) {
+		t.Errorf("got %d issues, want %d ending with BULK-%d", len(issues), total, total)
+	}
+	if n := countRequests(s, "GET /rest/api/2/search"); n != 3 {
+		t.Errorf("got %d searches, want 3", n)
+	}
+
+	issues, err = client.SearchAll("project = BULK", 30)
+	if err != nil {
+		t.Fatalf("searching with a limit: %s", err)
+	}
+	if len(issues) != 30 {
+		t.Errorf("got %d issues, want the limit of 30", len(issues))
+	}
+}
+
+func TestAddComment(t *testing.T) {
+	s, client := newServer(t)
+	now := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now }
+	if err := client.AddComment("PROJ-2", "Started on it"); err != nil {
+		t.Fatalf("commenting: %s", err)
+	}
+	issue, err := client.GetIssue("PROJ-2")
+	if err != nil {
+		t.Fatalf("getting the issue: %s", err)
+	}
+	if len(issue.Comments) != 1 {
+		t.Fatalf("got %d comments, want 1", len(issue.Comments))
+	}
+	comment := issue.Comments[0]
+	if comment.Body != "Started on it" || comment.Author != "Ada Lovelace" || !comment.Created.Equal(now) {
+		t.Errorf("got %+v, want the comment of Ada Lovelace at %s", comment, now)
+	}
+	if err := client.AddComment("NOPE-1", "Lost"); err == nil {
+		t.Error("commenting a missing issue succeeded")
+	}
+}
+
+func TestTransitionIssue(t *testing.T) {
+	s, client := newServer(t)
+	if err := client.TransitionIssue("PROJ-1", "in review"); err != nil {
+		t.Fatalf("moving the issue: %s", err)
+	}
+	if issue, _ := s.Issue("PROJ-1"); issue.Status != "In Review" {
+		t.Errorf("got status %q, want In Review", issue.Status)
+	}
+	// Done only leads back to To Do
+	if err := client.TransitionIssue("PROJ-3", "In Progress"); err == nil {
+		t.Error("moving the issue outside of the workflow succeeded")
+	}
+}
+
+func TestAssignIssue(t *testing.T) {
+	s, client := newServer(t)
+	if err := client.AssignIssue("PROJ-2", "alan@example.com"); err != nil {
+		t.Fatalf("assigning the issue: %s", err)
+	}
+	if issue, _ := s.Issue("PROJ-2"); issue.Assignee != "2" {
+		t.Errorf("got assignee %q, want 2", issue.Assignee)
+	}
+	if err := client.AssignIssue("PROJ-2", "me"); err != nil {
+		t.Fatalf("assigning the issue to me: %s", err)
+	}
+	if issue, _ := s.Issue("PROJ-2"); issue.Assignee != "1" {
+		t.Errorf("got assignee %q, want 1", issue.Assignee)
+	}
+	if err := client.AssignIssue("PROJ-2", "nobody"); err == nil {
+		t.Error("assigning the issue to an unknown user succeeded")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s, client := newServer(t)
+	s.RateLimit(1, time.Second)
+	start := time.Now()
+	if _, err := client.SearchIssues("project = PROJ"); err != nil {
+		t.Fatalf("searching after the rate limit: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the Retry-After of 1s", elapsed)
+	}
+	if n := countRequests(s, "GET /rest/api/2/search"); n != 2 {
+		t.Errorf("got %d searches, want 2", n)
+	}
+	state := client.RetryState()
+	if state.Retries != 1 || state.Status != http.StatusTooManyRequests {
+		t.Errorf("got retry state %+v, want one retry after a 429", state)
+	}
+}
+
+func TestDropIsOffline(t *testing.T) {
+	s, client := newServer(t)
+	s.Inject(jiratest.Failure{Method: http.MethodPost, Drop: true})
+	err := client.AddComment("PROJ-1", "Lost")
+	if !errors.Is(err, jira.ErrOffline) {
+		t.Errorf("got %v, want jira.ErrOffline", err)
+	}
+	if issue, _ := s.Issue("PROJ-1"); len(issue.Comments) != 1 {
+		t.Errorf("got %d comments, want the dropped one not added", len(issue.Comments))
+	}
+}
+
+func TestDelayKeepsFailures(t *testing.T) {
+	s, client := newServer(t)
+	latency := 50 * time.Millisecond
+	s.Delay(latency)
+	s.Inject(jiratest.Failure{Method: http.MethodPost, Drop: true})
+	start := time.Now()
+	if err := client.AddComment("PROJ-1", "Lost"); !errors.Is(err, jira.ErrOffline) {
+		t.Errorf("got %v, want jira.ErrOffline after the delay", err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("answered after %s, want at least %s", elapsed, latency)
+	}
+
+	start = time.Now()
+	if _, err := client.GetIssue("PROJ-1"); err != nil {
+		t.Errorf("getting the issue: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("answered after %s, want at least %s", elapsed, latency)
+	}
+}
@@ -0,0 +1,168 @@
+package jql
+
+import (
+	"strings"
+	"unicode"
+)
+
+type TokenKind uint8
+
+const (
+	Illegal TokenKind = iota
+	Field
+	Keyword
+	Operator
+	String
+	Value
+	Function
+	Punctuation
+)
+
+type Token struct {
+	Kind TokenKind
+	Text string // The raw text of the token, quotes included
+	Pos  int    // The rune offset of the first character of the token
+}
+
+// Reserved words of the language, compared case insensitively
+var keywords = map[string]bool{
+	"AND":     true,
+	"OR":      true,
+	"NOT":     true,
+	"IN":      true,
+	"IS":      true,
+	"WAS":     true,
+	"CHANGED": true,
+	"EMPTY":   true,
+	"NULL":    true,
+	"ORDER":   true,
+	"BY":      true,
+	"ASC":     true,
+	"DESC":    true,
+	"AFTER":   true,
+	"BEFORE":  true,
+	"DURING":  true,
+	"ON":      true,
+	"FROM":    true,
+	"TO":      true,
+}
+
+/**
+ * Tokenize splits a JQL query into tokens, the whitespace is discarded.
+ * The tokenizer never fails: unknown characters are returned as Illegal
+ * tokens so that the caller can still highlight the rest of the query.
+ * @param query string - The JQL query to tokenize
+ * @return []Token - The tokens of the query in order of appearance
+ */
+func Tokenize(query string) []Token {
+	src := []rune(query)
+	tokens := []Token{}
+	for i := 0; i < len(src); {
+		r := src[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end, _ := scanString(src, i)
+			tokens = append(tokens, Token{String, string(src[i:end]), i})
+			i = end
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, Token{Punctuation, string(r), i})
+			i++
+		case strings.ContainsRune("=!<>~", r):
+			end := scanOperator(src, i)
+			kind := Operator
+			if end == i {
+				// A lone '!' is not an operator
+				kind, end = Illegal, i+1
+			}
+			tokens = append(tokens, Token{kind, string(src[i:end]), i})
+			i = end
+		case isWordRune(r):
+			end := i
+			for end < len(src) && isWordRune(src[end]) {
+				end++
+			}
+			tokens = append(tokens, Token{Value, string(src[i:end]), i})
+			i = end
+		default:
+			tokens = append(tokens, Token{Illegal, string(r), i})
+			i++
+		}
+	}
+	classify(tokens)
+	return tokens
+}
+
+// scanString returns the end of the string starting at start, and whether its closing quote was found
+func scanString(src []rune, start int) (int, bool) {
+	quote := src[start]
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1, true
+		}
+	}
+	return len(src), false
+}
+
+func scanOperator(src []rune, start int) int {
+	for _, op := range []string{"!=", "!~", ">=", "<=", "=", "~", ">", "<"} {
+		if strings.HasPrefix(string(src[start:]), op) {
+			return start + len([]rune(op))
+		}
+	}
+	return start
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-/@:[]*+", r)
+}
+
+// classify refines the bare words returned by the scanner into keywords,
+// fields and function names by looking at the surrounding tokens.
+func classify(tokens []Token) {
+	inOrderBy := false
+	for i := range tokens {
+		t := &tokens[i]
+		if t.Kind != Value {
+			continue
+		}
+		upper := strings.ToUpper(t.Text)
+		if keywords[upper] {
+			t.Kind = Keyword
+			if upper == "BY" && i > 0 && strings.EqualFold(tokens[i-1].Text, "ORDER") {
+				inOrderBy = true
+			}
+			continue
+		}
+		next := nextToken(tokens, i)
+		switch {
+		case next != nil && next.Kind == Punctuation && next.Text == "(":
+			t.Kind = Function
+		case inOrderBy, next != nil && isComparison(*next):
+			t.Kind = Field
+		}
+	}
+}
+
+func nextToken(tokens []Token, i int) *Token {
+	if i+1 < len(tokens) {
+		return &tokens[i+1]
+	}
+	return nil
+}
+
+// isComparison reports whether the token can follow a field name
+func isComparison(t Token) bool {
+	if t.Kind == Operator {
+		return true
+	}
+	switch strings.ToUpper(t.Text) {
+	case "IN", "NOT", "IS", "WAS", "CHANGED":
+		return t.Kind == Value || t.Kind == Keyword
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package jql_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jql"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []jql.Token
+	}{
+		{"", []jql.Token{}},
+		{
+			`project = PROJ`,
+			[]jql.Token{{jql.Field, "project", 0}, {jql.Operator, "=", 8}, {jql.Value, "PROJ", 10}},
+		},
+		{
+			`summary ~ "a \"b\" c"`,
+			[]jql.Token{{jql.Field, "summary", 0}, {jql.Operator, "~", 8}, {jql.String, `"a \"b\" c"`, 10}},
+		},
+		{
+			`summary ~ "abc\"`,
+			[]jql.Token{{jql.Field, "summary", 0}, {jql.Operator, "~", 8}, {jql.String, `"abc\"`, 10}},
+		},
+		{
+			`status NOT IN ('To Do', Done)`,
+			[]jql.Token{
+				{jql.Field, "status", 0}, {jql.Keyword, "NOT", 7}, {jql.Keyword, "IN", 11},
+				{jql.Punctuation, "(", 14}, {jql.String, "'To Do'", 15}, {jql.Punctuation, ",", 22},
+				{jql.Value, "Done", 24}, {jql.Punctuation, ")", 28},
+			},
+		},
+		{
+			`assignee = currentUser() order by updated DESC`,
+			[]jql.Token{
+				{jql.Field, "assignee", 0}, {jql.Operator, "=", 9}, {jql.Function, "currentUser", 11},
+				{jql.Punctuation, "(", 22}, {jql.Punctuation, ")", 23}, {jql.Keyword, "order", 25},
+				{jql.Keyword, "by", 31}, {jql.Field, "updated", 34}, {jql.Keyword, "DESC", 42},
+			},
+		},
+		{
+			`priority >= High and due <= 2d`,
+			[]jql.Token{
+				{jql.Field, "priority", 0}, {jql.Operator, ">=", 9}, {jql.Value, "High", 12},
+				{jql.Keyword, "and", 17}, {jql.Field, "due", 21}, {jql.Operator, "<=", 25}, {jql.Value, "2d", 28},
+			},
+		},
+		{
+			`résumé ! x`,
+			[]jql.Token{{jql.Value, "résumé", 0}, {jql.Illegal, "!", 7}, {jql.Value, "x", 9}},
+		},
+	}
+	for _, test := range tests {
+		if got := jql.Tokenize(test.query); !slices.Equal(got, test.want) {
+			t.Errorf("Tokenize(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
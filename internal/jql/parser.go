@@ -0,0 +1,294 @@
+package jql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error describes a syntax error found by Validate
+type Error struct {
+	Pos int    // The rune offset in the query where the error was detected
+	Msg string // A human readable description of the error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+	end    int // The rune length of the query, used for errors at the end
+}
+
+/**
+ * Validate checks the syntax of a JQL query without contacting Jira.
+ * Field names and values are not checked against the instance, only the
+ * structure of the query is.
+ * @param query string - The JQL query to validate
+ * @return *Error - The first syntax error of the query or nil if the query is valid
+ */
+func Validate(query string) *Error {
+	p := parser{
+		tokens: Tokenize(query),
+		end:    len([]rune(query)),
+	}
+	return p.parseQuery()
+}
+
+func (p *parser) peek() *Token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// accept consumes the next token if it is one of the given words
+func (p *parser) accept(words ...string) bool {
+	t := p.peek()
+	if t == nil || t.Kind == String {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.Text, w) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...any) *Error {
+	pos := p.end
+	if t := p.peek(); t != nil {
+		pos = t.Pos
+	}
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// unexpected builds the error for the current token, or for the end of the query
+func (p *parser) unexpected(expected string) *Error {
+	t := p.peek()
+	if t == nil {
+		return p.errorf("expected %s but the query ended", expected)
+	}
+	return p.errorf("expected %s but found %q", expected, t.Text)
+}
+
+func (p *parser) parseQuery() *Error {
+	if p.peek() != nil && !p.isOrderBy() {
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+	}
+	if p.isOrderBy() {
+		p.pos += 2
+		if err := p.parseSort(); err != nil {
+			return err
+		}
+	}
+	if t := p.peek(); t != nil {
+		if t.Text == ")" {
+			return p.errorf("unbalanced closing parenthesis")
+		}
+		return p.unexpected("AND, OR or ORDER BY")
+	}
+	return nil
+}
+
+func (p *parser) isOrderBy() bool {
+	if p.pos+1 >= len(p.tokens) {
+		return false
+	}
+	return strings.EqualFold(p.tokens[p.pos].Text, "ORDER") &&
+		strings.EqualFold(p.tokens[p.pos+1].Text, "BY")
+}
+
+func (p *parser) parseOr() *Error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.accept("OR") {
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseAnd() *Error {
+	if err := p.parseNot(); err != nil {
+		return err
+	}
+	for p.accept("AND") {
+		if err := p.parseNot(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseNot() *Error {
+	if p.accept("NOT") {
+		return p.parseNot()
+	}
+	if t := p.peek(); t != nil && t.Text == "(" {
+		p.pos++
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		if !p.accept(")") {
+			return p.unexpected("a closing parenthesis")
+		}
+		return nil
+	}
+	return p.parseClause()
+}
+
+func (p *parser) parseClause() *Error {
+	if err := p.parseField(); err != nil {
+		return err
+	}
+	t := p.peek()
+	switch {
+	case t == nil:
+		return p.unexpected("an operator")
+	case t.Kind == Operator:
+		p.pos++
+		return p.parseOperand()
+	case p.accept("IN"):
+		return p.parseList()
+	case p.accept("NOT"):
+		if !p.accept("IN") {
+			return p.unexpected("IN")
+		}
+		return p.parseList()
+	case p.accept("IS"):
+		p.accept("NOT")
+		if !p.accept("EMPTY", "NULL") {
+			return p.unexpected("EMPTY or NULL")
+		}
+		return nil
+	case p.accept("WAS"):
+		p.accept("NOT")
+		var err *Error
+		if p.accept("IN") {
+			err = p.parseList()
+		} else {
+			err = p.parseOperand()
+		}
+		if err != nil {
+			return err
+		}
+		return p.parsePredicates()
+	case p.accept("CHANGED"):
+		return p.parsePredicates()
+	}
+	return p.unexpected("an operator")
+}
+
+func (p *parser) parseField() *Error {
+	t := p.peek()
+	if t == nil || (t.Kind != Field && t.Kind != Value && t.Kind != String) {
+		return p.unexpected("a field name")
+	}
+	p.pos++
+	return nil
+}
+
+// parseOperand parses a single value: a word, a string, a keyword such as EMPTY or a function call
+func (p *parser) parseOperand() *Error {
+	t := p.peek()
+	switch {
+	case t == nil:
+		return p.unexpected("a value")
+	case t.Kind == String:
+		// The last quote may be escaped, only the scanner knows
+		if _, closed := scanString([]rune(t.Text), 0); !closed {
+			return p.errorf("unterminated string")
+		}
+		p.pos++
+		return nil
+	case t.Kind == Function:
+		p.pos++
+		return p.parseArguments()
+	case t.Kind == Value:
+		p.pos++
+		return nil
+	case p.accept("EMPTY", "NULL"):
+		return nil
+	}
+	return p.unexpected("a value")
+}
+
+func (p *parser) parseArguments() *Error {
+	if !p.accept("(") {
+		return p.unexpected("an opening parenthesis")
+	}
+	if p.accept(")") {
+		return nil
+	}
+	for {
+		if err := p.parseOperand(); err != nil {
+			return err
+		}
+		if p.accept(")") {
+			return nil
+		}
+		if !p.accept(",") {
+			return p.unexpected("a comma or a closing parenthesis")
+		}
+	}
+}
+
+// parseList parses the right hand side of IN: a parenthesised list or a function
+func (p *parser) parseList() *Error {
+	if t := p.peek(); t != nil && t.Kind == Function {
+		p.pos++
+		return p.parseArguments()
+	}
+	if !p.accept("(") {
+		return p.unexpected("a list of values")
+	}
+	for {
+		if err := p.parseOperand(); err != nil {
+			return err
+		}
+		if p.accept(")") {
+			return nil
+		}
+		if !p.accept(",") {
+			return p.unexpected("a comma or a closing parenthesis")
+		}
+	}
+}
+
+// parsePredicates parses the optional history predicates of WAS and CHANGED
+func (p *parser) parsePredicates() *Error {
+	for {
+		switch {
+		case p.accept("AFTER", "BEFORE", "ON", "FROM", "TO", "BY"):
+			if err := p.parseOperand(); err != nil {
+				return err
+			}
+		case p.accept("DURING"):
+			if err := p.parseList(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseSort() *Error {
+	for {
+		if err := p.parseField(); err != nil {
+			return err
+		}
+		p.accept("ASC", "DESC")
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package jql_test
+
+import (
+	"testing"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jql"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		query string
+		want  *jql.Error // nil if the query is valid
+	}{
+		{"", nil},
+		{"project = PROJ AND status != Done ORDER BY key DESC", nil},
+		{`summary ~ "a \"quoted\" word"`, nil},
+		{"status IN (Open, 'In Progress') OR NOT assignee IS EMPTY", nil},
+		{"assignee WAS currentUser() BEFORE -1w", nil},
+		{"status CHANGED FROM Open TO Done", nil},
+		{"(project = A OR project = B) AND labels NOT IN membersOf(team)", nil},
+		{"ORDER BY created", nil},
+		{`summary ~ "abc`, &jql.Error{Pos: 10, Msg: "unterminated string"}},
+		{`summary ~ "abc\"`, &jql.Error{Pos: 10, Msg: "unterminated string"}},
+		{`summary ~ 'abc\'`, &jql.Error{Pos: 10, Msg: "unterminated string"}},
+		{"project =", &jql.Error{Pos: 9, Msg: "expected a value but the query ended"}},
+		{"project PROJ", &jql.Error{Pos: 8, Msg: `expected an operator but found "PROJ"`}},
+		{"status NOT Done", &jql.Error{Pos: 11, Msg: `expected IN but found "Done"`}},
+		{"status IN (Open Done)", &jql.Error{Pos: 16, Msg: `expected a comma or a closing parenthesis but found "Done"`}},
+		{"assignee IS me", &jql.Error{Pos: 12, Msg: `expected EMPTY or NULL but found "me"`}},
+	}
+	for _, test := range tests {
+		got := jql.Validate(test.query)
+		switch {
+		case got == nil && test.want == nil:
+		case got == nil || test.want == nil || *got != *test.want:
+			t.Errorf("Validate(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
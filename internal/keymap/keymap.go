@@ -0,0 +1,380 @@
+// Package keymap holds the key bindings of the TUI. The bindings are grouped
+// by context, the states of the TUI where they apply, and every binding can be
+// changed from the keys section of the config file:
+//
+//	keys:
+//	  issues:
+//	    transition: [T]
+//	  detail:
+//	    comment: [c, m]
+//
+// An empty list of keys disables the action.
+package keymap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// The contexts of the bindings
+const (
+	ContextGlobal  = "global"  // Every state but the modal ones
+	ContextIssues  = "issues"  // The list and the card of the issue
+	ContextList    = "list"    // The list of issues is focused
+	ContextDetail  = "detail"  // The card of the issue is focused
+	ContextSearch  = "search"  // The query is being typed
+	ContextPicker  = "picker"  // A list of choices is open
+	ContextComment = "comment" // A comment is being written
+	ContextPrompt  = "prompt"  // A question is asked
+	ContextQueue   = "queue"   // The queued changes are shown
+	ContextExport  = "export"  // The export form is open
+)
+
+// Global applies in every state, ForceQuit even in the modal ones
+type Global struct {
+	ForceQuit key.Binding
+	Help      key.Binding
+	NewTab    key.Binding
+}
+
+// Issues are the actions on the selected issue and on the tabs
+type Issues struct {
+	Quit            key.Binding
+	Search          key.Binding
+	Transition      key.Binding
+	Assign          key.Binding
+	Refresh         key.Binding
+	RefreshInterval key.Binding
+	Queue           key.Binding
+	Export          key.Binding
+	Browser         key.Binding
+	Copy            key.Binding
+	Branch          key.Binding
+	CloseTab        key.Binding
+	NextTab         key.Binding
+	PrevTab         key.Binding
+	GrowList        key.Binding
+	ShrinkList      key.Binding
+	ToggleList      key.Binding
+	ToggleCard      key.Binding
+	Layout          key.Binding
+}
+
+// List applies while the list of issues is focused, the bindings moving
+// through it replace the ones of the bubbles list
+type List struct {
+	Open         key.Binding
+	Profiles     key.Binding
+	SavedQueries key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	PrevPage     key.Binding
+	NextPage     key.Binding
+	Start        key.Binding
+	End          key.Binding
+}
+
+// Detail applies while the card of the issue is focused
+type Detail struct {
+	Back    key.Binding
+	Comment key.Binding
+}
+
+// Search applies while the query is typed
+type Search struct {
+	Submit  key.Binding
+	Cancel  key.Binding
+	Older   key.Binding
+	Newer   key.Binding
+	History key.Binding
+}
+
+// Picker applies while a list of choices is open
+type Picker struct {
+	Choose key.Binding
+	Cancel key.Binding
+}
+
+// Comment applies while a comment is written
+type Comment struct {
+	Send   key.Binding
+	Cancel key.Binding
+}
+
+// Prompt applies while a question is asked, Confirm only for the single line answers
+type Prompt struct {
+	Confirm key.Binding
+	Submit  key.Binding
+	Cancel  key.Binding
+}
+
+// Queue applies while the queued changes are shown
+type Queue struct {
+	Edit    key.Binding
+	Retry   key.Binding
+	Discard key.Binding
+	Close   key.Binding
+}
+
+// Export applies while the export form is open
+type Export struct {
+	Up     key.Binding
+	Down   key.Binding
+	Next   key.Binding
+	Prev   key.Binding
+	Start  key.Binding
+	Cancel key.Binding
+}
+
+// KeyMap holds the bindings of every context
+type KeyMap struct {
+	Global  Global
+	Issues  Issues
+	List    List
+	Detail  Detail
+	Search  Search
+	Picker  Picker
+	Comment Comment
+	Prompt  Prompt
+	Queue   Queue
+	Export  Export
+}
+
+// binding creates a binding shown in the help with its keys
+func binding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(helpKeys(keys), desc))
+}
+
+// helpKeys shows the keys of a binding in the help
+func helpKeys(keys []string) string {
+	shown := []string{}
+	for _, k := range keys {
+		switch k {
+		case " ":
+			k = "space"
+		case "up":
+			k = "↑"
+		case "down":
+			k = "↓"
+		case "left":
+			k = "←"
+		case "right":
+			k = "→"
+		}
+		shown = append(shown, k)
+	}
+	return strings.Join(shown, "/")
+}
+
+/**
+ * Default returns the bindings used when the config file changes none
+ * @return KeyMap - The default bindings
+ */
+func Default() KeyMap {
+	return KeyMap{
+		Global: Global{
+			ForceQuit: binding("quit", "ctrl+c"),
+			Help:      binding("help", "?"),
+			NewTab:    binding("new tab", "ctrl+t"),
+		},
+		Issues: Issues{
+			Quit:            binding("quit", "q"),
+			Search:          binding("search", "/"),
+			Transition:      binding("transition", "t"),
+			Assign:          binding("assign", "a"),
+			Refresh:         binding("refresh", "r"),
+			RefreshInterval: binding("refresh interval", "R"),
+			Queue:           binding("queued changes", "Q"),
+			Export:          binding("export", "e"),
+			Browser:         binding("open in browser", "o"),
+			Copy:            binding("copy", "y"),
+			Branch:          binding("git branch", "b"),
+			CloseTab:        binding("close tab", "ctrl+w"),
+			NextTab:         binding("next tab", "tab"),
+			PrevTab:         binding("previous tab", "shift+tab"),
+			GrowList:        binding("grow list", "+", "="),
+			ShrinkList:      binding("shrink list", "-"),
+			ToggleList:      binding("toggle list", "L"),
+			ToggleCard:      binding("toggle card", "C"),
+			Layout:          binding("layouts", "v"),
+		},
+		List: List{
+			Open:         binding("open", "enter"),
+			Profiles:     binding("profiles", "p"),
+			SavedQueries: binding("saved queries", "s"),
+			// The keys of the bubbles list, without the letters of the actions on the issues
+			Up:       binding("up", "up", "k"),
+			Down:     binding("down", "down", "j"),
+			PrevPage: binding("prev page", "left", "pgup"),
+			NextPage: binding("next page", "right", "pgdown"),
+			Start:    binding("go to start", "home", "g"),
+			End:      binding("go to end", "end", "G"),
+		},
+		Detail: Detail{
+			Back:    binding("back", "enter", "esc"),
+			Comment: binding("comment", "m"),
+		},
+		Search: Search{
+			Submit:  binding("search", "enter"),
+			Cancel:  binding("cancel", "esc"),
+			Older:   binding("older query", "up"),
+			Newer:   binding("newer query", "down"),
+			History: binding("search the history", "ctrl+r"),
+		},
+		Picker: Picker{
+			Choose: binding("choose", "enter"),
+			Cancel: binding("cancel", "esc", "q"),
+		},
+		Comment: Comment{
+			Send:   binding("send", "ctrl+s"),
+			Cancel: binding("cancel", "esc"),
+		},
+		Prompt: Prompt{
+			Confirm: binding("confirm", "enter"),
+			Submit:  binding("confirm", "ctrl+s"),
+			Cancel:  binding("cancel", "esc"),
+		},
+		Queue: Queue{
+			Edit:    binding("edit", "e"),
+			Retry:   binding("retry, sending conflicts anyway", "r"),
+			Discard: binding("discard", "d", "x"),
+			Close:   binding("back", "esc", "q", "Q"),
+		},
+		Export: Export{
+			Up:     binding("up", "up", "shift+tab"),
+			Down:   binding("down", "down", "tab"),
+			Next:   binding("change", " ", "right", "l"),
+			Prev:   binding("change back", "left", "h"),
+			Start:  binding("export", "enter"),
+			Cancel: binding("back", "esc"),
+		},
+	}
+}
+
+/**
+ * bindings lists the bindings of every context by the names used in the config file
+ * @return map[string]map[string]*key.Binding - The bindings by action, by context
+ */
+func (km *KeyMap) bindings() map[string]map[string]*key.Binding {
+	return map[string]map[string]*key.Binding{
+		ContextGlobal: {
+			"force_quit": &km.Global.ForceQuit,
+			"help":       &km.Global.Help,
+			"new_tab":    &km.Global.NewTab,
+		},
+		ContextIssues: {
+			"quit":             &km.Issues.Quit,
+			"search":           &km.Issues.Search,
+			"transition":       &km.Issues.Transition,
+			"assign":           &km.Issues.Assign,
+			"refresh":          &km.Issues.Refresh,
+			"refresh_interval": &km.Issues.RefreshInterval,
+			"queue":            &km.Issues.Queue,
+			"export":           &km.Issues.Export,
+			"browser":          &km.Issues.Browser,
+			"copy":             &km.Issues.Copy,
+			"branch":           &km.Issues.Branch,
+			"close_tab":        &km.Issues.CloseTab,
+			"next_tab":         &km.Issues.NextTab,
+			"prev_tab":         &km.Issues.PrevTab,
+			"grow_list":        &km.Issues.GrowList,
+			"shrink_list":      &km.Issues.ShrinkList,
+			"toggle_list":      &km.Issues.ToggleList,
+			"toggle_card":      &km.Issues.ToggleCard,
+			"layout":           &km.Issues.Layout,
+		},
+		ContextList: {
+			"open":          &km.List.Open,
+			"profiles":      &km.List.Profiles,
+			"saved_queries": &km.List.SavedQueries,
+			"up":            &km.List.Up,
+			"down":          &km.List.Down,
+			"prev_page":     &km.List.PrevPage,
+			"next_page":     &km.List.NextPage,
+			"start":         &km.List.Start,
+			"end":           &km.List.End,
+		},
+		ContextDetail: {
+			"back":    &km.Detail.Back,
+			"comment": &km.Detail.Comment,
+		},
+		ContextSearch: {
+			"submit":  &km.Search.Submit,
+			"cancel":  &km.Search.Cancel,
+			"older":   &km.Search.Older,
+			"newer":   &km.Search.Newer,
+			"history": &km.Search.History,
+		},
+		ContextPicker: {
+			"choose": &km.Picker.Choose,
+			"cancel": &km.Picker.Cancel,
+		},
+		ContextComment: {
+			"send":   &km.Comment.Send,
+			"cancel": &km.Comment.Cancel,
+		},
+		ContextPrompt: {
+			"confirm": &km.Prompt.Confirm,
+			"submit":  &km.Prompt.Submit,
+			"cancel":  &km.Prompt.Cancel,
+		},
+		ContextQueue: {
+			"edit":    &km.Queue.Edit,
+			"retry":   &km.Queue.Retry,
+			"discard": &km.Queue.Discard,
+			"close":   &km.Queue.Close,
+		},
+		ContextExport: {
+			"up":     &km.Export.Up,
+			"down":   &km.Export.Down,
+			"next":   &km.Export.Next,
+			"prev":   &km.Export.Prev,
+			"start":  &km.Export.Start,
+			"cancel": &km.Export.Cancel,
+		},
+	}
+}
+
+/**
+ * New returns the default bindings changed by the keys section of the config file
+ * @param overrides map[string]map[string][]string - The keys of the actions, by context
+ * @return KeyMap - The bindings
+ * @return error - An error if a context or an action is unknown
+ */
+func New(overrides map[string]map[string][]string) (KeyMap, error) {
+	km := Default()
+	bindings := km.bindings()
+	for _, context := range sortedKeys(overrides) {
+		actions, ok := bindings[context]
+		if !ok {
+			return km, fmt.Errorf("unknown context %q, use %s", context, strings.Join(sortedKeys(bindings), ", "))
+		}
+		for _, action := range sortedKeys(overrides[context]) {
+			b, ok := actions[action]
+			if !ok {
+				return km, fmt.Errorf("unknown action %q in %s, use %s", action, context, strings.Join(sortedKeys(actions), ", "))
+			}
+			keys := overrides[context][action]
+			if len(keys) == 0 {
+				b.Unbind()
+				continue
+			}
+			b.SetKeys(keys...)
+			b.SetHelp(helpKeys(keys), b.Help().Desc)
+		}
+	}
+	return km, nil
+}
+
+// sortedKeys returns the keys of a map in order, for stable errors
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,120 @@
+// Package logging configures the structured logger of the application.
+// The logs go to a file: the terminal belongs to the TUI while it runs.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+// The level used when none is given, the failures only
+const DefaultLevel = "warn"
+
+// The replacement of the redacted values
+const Redacted = "[REDACTED]"
+
+// The attributes that may hold a secret, compared case insensitively
+var secretKeys = map[string]bool{
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+	"password":      true,
+	"passphrase":    true,
+	"secret":        true,
+}
+
+// The credentials that may appear in a message, such as in the text of an error
+var credentialPattern = regexp.MustCompile(`(?i)\b(basic|bearer)\s+[A-Za-z0-9._~+/\-]+=*`)
+
+/**
+ * DefaultPath returns the path of the log file under the XDG state directory
+ * @return string - The path of the log file
+ */
+func DefaultPath() string {
+	return filepath.Join(xdg.StateHome(), "jiratui.log")
+}
+
+/**
+ * Setup makes the default slog logger, and the log package, write to a file
+ * @param path string - The path of the log file, empty to discard the logs
+ * @param level string - The lowest level logged: debug, info, warn or error
+ * @return io.Closer - The log file, to close when the program exits
+ * @return error - An error if the level is unknown or the file cannot be opened
+ */
+func Setup(path string, level string) (io.Closer, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q, use debug, info, warn or error", level)
+	}
+
+	var out io.WriteCloser = nopCloser{io.Discard}
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+	}
+
+	handler := slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level:       l,
+		ReplaceAttr: redactAttr,
+	})
+	slog.SetDefault(slog.New(handler))
+	return out, nil
+}
+
+// redactAttr hides the values of the secret attributes and the credentials in the messages
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if secretKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+	if a.Value.Kind() == slog.KindString || a.Value.Kind() == slog.KindAny {
+		text := a.Value.String()
+		if redacted := RedactCredentials(text); redacted != text {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return a
+}
+
+/**
+ * RedactCredentials hides the basic and bearer credentials found in a text
+ * @param text string - The text to redact
+ * @return string - The text with the credentials replaced by Redacted
+ */
+func RedactCredentials(text string) string {
+	return credentialPattern.ReplaceAllString(text, "$1 "+Redacted)
+}
+
+/**
+ * IsSecret reports whether the value of a header or a parameter must not be logged
+ * @param name string - The name of the header or of the parameter
+ * @return bool - True if the value may hold a secret
+ */
+func IsSecret(name string) bool {
+	name = strings.ToLower(name)
+	return secretKeys[name] || strings.Contains(name, "token") || strings.Contains(name, "secret")
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package output prints issues for scripts, in the formats of the
+// non-interactive commands and the exports: JSON, CSV, TSV, aligned tables
+// and Markdown tables.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// The output formats
+const (
+	JSON     = "json"
+	CSV      = "csv"
+	TSV      = "tsv"
+	Table    = "table"
+	Markdown = "markdown"
+)
+
+// Formats lists the output formats, in the order shown by the help
+var Formats = []string{Table, JSON, CSV, TSV, Markdown}
+
+// DefaultFields are the columns printed when none is asked for
+var DefaultFields = []string{"key", "status", "assignee", "summary"}
+
+// The values of the fields, by name. The JSON output keeps the types,
+// the other formats print them with text.
+var fieldValues = map[string]func(i jira.Issue) any{
+	"key":         func(i jira.Issue) any { return i.Key },
+	"summary":     func(i jira.Issue) any { return i.Summary },
+	"status":      func(i jira.Issue) any { return i.Status },
+	"assignee":    func(i jira.Issue) any { return i.Assignee },
+	"reporter":    func(i jira.Issue) any { return i.Reporter },
+	"type":        func(i jira.Issue) any { return i.Type },
+	"priority":    func(i jira.Issue) any { return i.Priority },
+	"labels":      func(i jira.Issue) any { return i.Labels },
+	"created":     func(i jira.Issue) any { return i.Created },
+	"updated":     func(i jira.Issue) any { return i.Updated },
+	"description": func(i jira.Issue) any { return i.Description },
+}
+
+// Fields lists the names of the fields, in the order shown by the help
+var Fields = []string{"key", "summary", "status", "assignee", "reporter", "type", "priority", "labels", "created", "updated", "description"}
+
+/**
+ * ParseFields reads a comma separated list of fields, such as "key,summary"
+ * @param spec string - The list of fields, empty for DefaultFields
+ * @return []string - The names of the fields, lower case
+ * @return error - An error naming the first unknown field
+ */
+func ParseFields(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultFields, nil
+	}
+	fields := []string{}
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := fieldValues[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q, use %s", name, strings.Join(Fields, ", "))
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+/**
+ * Value returns a field of an issue as text, dates in RFC 3339 and lists comma separated
+ * @param issue jira.Issue - The issue
+ * @param field string - The name of the field, checked by ParseFields
+ * @return string - The text of the field, empty if it is not set
+ */
+func Value(issue jira.Issue, field string) string {
+	switch v := fieldValues[field](issue).(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ",")
+	case string:
+		return v
+	}
+	return ""
+}
+
+/**
+ * Write prints the issues in a format
+ * @param w io.Writer - Where to print the issues
+ * @param format string - One of Formats
+ * @param issues []jira.Issue - The issues to print
+ * @param fields []string - The fields to print, as returned by ParseFields
+ * @return error - An error if the format is unknown or the writer fails
+ */
+func Write(w io.Writer, format string, issues []jira.Issue, fields []string) error {
+	switch format {
+	case JSON:
+		return writeJSON(w, issues, fields)
+	case CSV:
+		return writeCSV(w, issues, fields)
+	case TSV:
+		return writeTSV(w, issues, fields)
+	case Table:
+		return writeTable(w, issues, fields)
+	case Markdown:
+		return writeMarkdown(w, issues, fields)
+	}
+	return fmt.Errorf("unknown output %q, use %s", format, strings.Join(Formats, ", "))
+}
+
+func writeJSON(w io.Writer, issues []jira.Issue, fields []string) error {
+	result := []map[string]any{}
+	for _, issue := range issues {
+		object := map[string]any{}
+		for _, field := range fields {
+			object[field] = fieldValues[field](issue)
+		}
+		result = append(result, object)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+func writeCSV(w io.Writer, issues []jira.Issue, fields []string) error {
+	writer := csv.NewWriter(w)
+	writer.Write(fields)
+	for _, issue := range issues {
+		row := []string{}
+		for _, field := range fields {
+			row = append(row, Value(issue, field))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// The TSV output has no quoting, the tabs and the new lines of the values become spaces
+var tsvReplacer = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+func writeTSV(w io.Writer, issues []jira.Issue, fields []string) error {
+	if _, err := fmt.Fprintln(w, strings.Join(fields, "\t")); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		row := []string{}
+		for _, field := range fields {
+			row = append(row, tsvReplacer.Replace(Value(issue, field)))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The longest value printed in a table cell, the descriptions would make the rows unreadable
+const maxCellWidth = 60
+
+func writeTable(w io.Writer, issues []jira.Issue, fields []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(fields, "\t")))
+	for _, issue := range issues {
+		row := []string{}
+		for _, field := range fields {
+			row = append(row, truncate(tsvReplacer.Replace(Value(issue, field)), maxCellWidth))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// The cells of a Markdown table are on one line, the pipes would end them
+var markdownReplacer = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "")
+
+func writeMarkdown(w io.Writer, issues []jira.Issue, fields []string) error {
+	separator := []string{}
+	for range fields {
+		separator = append(separator, "---")
+	}
+	rows := []string{"| " + strings.Join(fields, " | ") + " |", "| " + strings.Join(separator, " | ") + " |"}
+	for _, issue := range issues {
+		row := []string{}
+		for _, field := range fields {
+			row = append(row, markdownReplacer.Replace(Value(issue, field)))
+		}
+		rows = append(rows, "| "+strings.Join(row, " | ")+" |")
+	}
+	_, err := fmt.Fprintln(w, strings.Join(rows, "\n"))
+	return err
+}
+
+// truncate shortens the text to width runes, ending it with an ellipsis
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
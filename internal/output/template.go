@@ -0,0 +1,138 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// The names of the colours accepted by the color helper, ANSI numbers and #rrggbb are accepted too
+var colorNames = map[string]string{
+	"black":   "0",
+	"red":     "1",
+	"green":   "2",
+	"yellow":  "3",
+	"blue":    "4",
+	"magenta": "5",
+	"cyan":    "6",
+	"white":   "7",
+	"gray":    "8",
+}
+
+// The helpers of the templates, on top of the builtins of text/template
+var templateFuncs = template.FuncMap{
+	"date":     formatDate,
+	"ago":      ago,
+	"truncate": truncateHelper,
+	"pad":      pad,
+	"color":    color,
+	"bold":     func(text string) string { return lipgloss.NewStyle().Bold(true).Render(text) },
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"join":     func(sep string, values []string) string { return strings.Join(values, sep) },
+	"json":     toJSON,
+}
+
+/**
+ * ParseTemplate reads a template of the --template option, like the --format of
+ * docker: the template is run for each issue and followed by a new line.
+ * A value starting with @ is the path of a template file.
+ * @param spec string - The template or @ followed by the path of a template file
+ * @return *template.Template - The parsed template
+ * @return error - The error encountered while reading or parsing the template
+ */
+func ParseTemplate(spec string) (*template.Template, error) {
+	name := "template"
+	if path, ok := strings.CutPrefix(spec, "@"); ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// The files end with a new line, the issues are already separated by one
+		name, spec = path, strings.TrimSuffix(string(content), "\n")
+	}
+	return template.New(name).Funcs(templateFuncs).Parse(spec)
+}
+
+/**
+ * WriteTemplate prints each issue with a template
+ * @param w io.Writer - Where to print the issues
+ * @param tmpl *template.Template - The template returned by ParseTemplate, run with a jira.Issue
+ * @param issues []jira.Issue - The issues to print
+ * @return error - The first error of the template or of the writer
+ */
+func WriteTemplate(w io.Writer, tmpl *template.Template, issues []jira.Issue) error {
+	for _, issue := range issues {
+		if err := tmpl.Execute(w, issue); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatDate formats a date with a Go layout such as "2006-01-02", the zero date is empty
+func formatDate(layout string, date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Local().Format(layout)
+}
+
+// ago describes how long ago a date was, such as "5m", "3h" or "2d"
+func ago(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	elapsed := time.Since(date)
+	switch {
+	case elapsed < time.Minute:
+		return "now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(elapsed.Hours()))
+	case elapsed < 365*24*time.Hour:
+		return fmt.Sprintf("%dd", int(elapsed.Hours()/24))
+	}
+	return fmt.Sprintf("%dy", int(elapsed.Hours()/24/365))
+}
+
+// truncateHelper takes the width first, so that it can end a pipeline: {{.Summary | truncate 40}}
+func truncateHelper(width int, text string) string {
+	if width <= 0 {
+		return ""
+	}
+	return truncate(text, width)
+}
+
+// pad fills the text with spaces up to width columns, to align the values: {{.Key | pad 10}}
+func pad(width int, text string) string {
+	if n := width - lipgloss.Width(text); n > 0 {
+		return text + strings.Repeat(" ", n)
+	}
+	return text
+}
+
+// color paints the text unless the output is not a terminal or NO_COLOR is set
+func color(name string, text string) string {
+	if number, ok := colorNames[strings.ToLower(name)]; ok {
+		name = number
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(name)).Render(text)
+}
+
+func toJSON(value any) (string, error) {
+	content, err := json.Marshal(value)
+	return string(content), err
+}
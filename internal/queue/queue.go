@@ -0,0 +1,270 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+type Kind string
+
+const (
+	Comment    Kind = "comment"
+	Transition Kind = "transition"
+	Assign     Kind = "assign"
+)
+
+type Status string
+
+const (
+	Pending  Status = "pending"  // Waiting to be sent
+	Failed   Status = "failed"   // Rejected by Jira, see the error
+	Conflict Status = "conflict" // The issue changed remotely after the entry was queued
+)
+
+// An Entry is a change to an issue waiting to be sent to Jira
+type Entry struct {
+	ID       int       `json:"id"`
+	Kind     Kind      `json:"kind"`
+	IssueKey string    `json:"issue_key"`
+	Value    string    `json:"value"`   // The comment, the target status or the assignee
+	Updated  time.Time `json:"updated"` // When the issue was last updated as far as the user knew
+	Status   Status    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Force    bool      `json:"force,omitempty"` // Send even if the issue changed remotely
+	Queued   time.Time `json:"queued"`
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s %s: %s", e.Kind, e.IssueKey, e.Value)
+}
+
+// Queue keeps the changes made while Jira is not reachable, it is saved
+// to disk after every change so that nothing is lost on exit
+type Queue struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry
+	nextID  int
+	broken  error // Why the file could not be read, it is never overwritten then
+}
+
+/**
+ * DefaultPath returns the path of the queue of a profile
+ * @param profile string - The name of the profile
+ * @return string - The path of the file under the XDG state directory
+ */
+func DefaultPath(profile string) string {
+	return filepath.Join(xdg.StateHome(), "queue", profile+".json")
+}
+
+/**
+ * Load reads the queue from the given file, a missing file is an empty queue.
+ * A corrupt file is renamed aside with the .corrupt suffix, so that the
+ * changes it holds can still be recovered by hand.
+ * @param path string - The path of the queue file
+ * @return *Queue - The loaded queue, usable even if an error is returned
+ * @return error - The error encountered while reading the file, if any
+ */
+func Load(path string) (*Queue, error) {
+	q := &Queue{path: path, entries: []Entry{}, nextID: 1}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	} else if err != nil {
+		q.broken = err
+		return q, err
+	}
+	if err := json.Unmarshal(content, &q.entries); err != nil {
+		q.entries = []Entry{}
+		aside := path + ".corrupt"
+		if renameErr := os.Rename(path, aside); renameErr != nil {
+			q.broken = err
+			return q, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return q, fmt.Errorf("parsing %s, moved to %s: %w", path, aside, err)
+	}
+	for _, e := range q.entries {
+		q.nextID = max(q.nextID, e.ID+1)
+	}
+	return q, nil
+}
+
+func (q *Queue) save() error {
+	if q.broken != nil {
+		// The changes in the file would be lost
+		return fmt.Errorf("the queue file %s could not be read, it is not replaced: %w", q.path, q.broken)
+	}
+	content, err := json.MarshalIndent(q.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o700); err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+/**
+ * Add appends a change to the queue
+ * @param kind Kind - The kind of change
+ * @param issue jira.Issue - The issue to change, as last seen by the user
+ * @param value string - The comment, the target status or the assignee
+ * @return error - The error encountered while saving the queue, if any
+ */
+func (q *Queue) Add(kind Kind, issue jira.Issue, value string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries = append(q.entries, Entry{
+		ID:       q.nextID,
+		Kind:     kind,
+		IssueKey: issue.Key,
+		Value:    value,
+		Updated:  issue.Updated,
+		Status:   Pending,
+		Queued:   time.Now(),
+	})
+	q.nextID++
+	return q.save()
+}
+
+/**
+ * Entries returns a copy of the queued changes, oldest first
+ * @return []Entry - The queued changes
+ */
+func (q *Queue) Entries() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Entry{}, q.entries...)
+}
+
+/**
+ * Edit replaces the value of an entry and queues it again
+ * @param id int - The id of the entry
+ * @param value string - The new comment, target status or assignee
+ * @return error - The error encountered while saving the queue, if any
+ */
+func (q *Queue) Edit(id int, value string) error {
+	return q.update(id, func(e *Entry) {
+		e.Value = value
+		e.Status = Pending
+		e.Error = ""
+	})
+}
+
+/**
+ * Retry queues a failed entry again. A conflicting entry is sent even
+ * though the issue changed remotely.
+ * @param id int - The id of the entry
+ * @return error - The error encountered while saving the queue, if any
+ */
+func (q *Queue) Retry(id int) error {
+	return q.update(id, func(e *Entry) {
+		e.Force = e.Force || e.Status == Conflict
+		e.Status = Pending
+		e.Error = ""
+	})
+}
+
+/**
+ * Discard removes an entry without sending it
+ * @param id int - The id of the entry
+ * @return error - The error encountered while saving the queue, if any
+ */
+func (q *Queue) Discard(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, e := range q.entries {
+		if e.ID == id {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return q.save()
+		}
+	}
+	return nil
+}
+
+/**
+ * entry returns the current state of an entry
+ * @param id int - The id of the entry
+ * @return Entry - A copy of the entry
+ * @return bool - False if the entry was discarded
+ */
+func (q *Queue) entry(id int) (Entry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+/**
+ * settle records the result of sending an entry, unless the entry was
+ * edited or discarded while it was sent: the edited entry is sent again
+ * @param sent Entry - The entry as it was sent
+ * @param change func(e *Entry) - The change of the entry, nil to remove it
+ * @return error - The error encountered while saving the queue, if any
+ */
+func (q *Queue) settle(sent Entry, change func(e *Entry)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.entries {
+		if q.entries[i].ID != sent.ID {
+			continue
+		}
+		if q.entries[i] != sent {
+			return nil
+		}
+		if change == nil {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		} else {
+			change(&q.entries[i])
+		}
+		return q.save()
+	}
+	return nil
+}
+
+func (q *Queue) update(id int, change func(e *Entry)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.entries {
+		if q.entries[i].ID == id {
+			change(&q.entries[i])
+			return q.save()
+		}
+	}
+	return nil
+}
+
+/**
+ * Count returns the number of entries waiting to be sent and of the
+ * entries needing the attention of the user
+ * @return int, int - The pending entries and the failed or conflicting ones
+ */
+func (q *Queue) Count() (int, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending, problems := 0, 0
+	for _, e := range q.entries {
+		if e.Status == Pending {
+			pending++
+		} else {
+			problems++
+		}
+	}
+	return pending, problems
+}
@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/jira"
+)
+
+// A Sender applies the queued changes to Jira, implemented by jira.Client
+type Sender interface {
+	IssueUpdated(key string) (time.Time, error)
+	AddComment(key string, comment string) error
+	TransitionIssue(key string, status string) error
+	AssignIssue(key string, user string) error
+}
+
+/**
+ * Replay sends the pending entries in order. Sent entries are removed,
+ * rejected ones are marked as failed and the ones whose issue changed
+ * remotely since they were queued are marked as conflicts. The replay
+ * stops at the first entry that cannot reach Jira.
+ * @param q *Queue - The queue to replay
+ * @param sender Sender - The client sending the changes
+ * @return int - The number of entries sent
+ * @return error - ErrOffline if Jira is still not reachable, or the error saving the queue
+ */
+func Replay(q *Queue, sender Sender) (int, error) {
+	sent := 0
+	// The issues changed by this replay, their update time is ours
+	changed := map[string]bool{}
+	for _, queued := range q.Entries() {
+		// The entry may have been edited or discarded since the replay started
+		e, ok := q.entry(queued.ID)
+		if !ok || e.Status != Pending {
+			continue
+		}
+		err := send(sender, e, changed[e.IssueKey])
+		if errors.Is(err, jira.ErrOffline) {
+			return sent, err
+		}
+
+		var saveErr error
+		var conflict *conflictError
+		switch {
+		case err == nil:
+			sent++
+			changed[e.IssueKey] = true
+			saveErr = q.settle(e, nil)
+		case errors.As(err, &conflict):
+			saveErr = q.settle(e, func(e *Entry) {
+				e.Status = Conflict
+				e.Error = err.Error()
+			})
+		default:
+			saveErr = q.settle(e, func(e *Entry) {
+				e.Status = Failed
+				e.Error = err.Error()
+			})
+		}
+		if saveErr != nil {
+			return sent, saveErr
+		}
+	}
+	return sent, nil
+}
+
+type conflictError struct {
+	updated time.Time
+}
+
+func (e *conflictError) Error() string {
+	return "the issue was updated remotely on " + e.updated.Local().Format("2 Jan 15:04")
+}
+
+// send applies a change, comments never conflict since they do not overwrite anything
+func send(sender Sender, e Entry, skipCheck bool) error {
+	if !skipCheck && !e.Force && e.Kind != Comment && !e.Updated.IsZero() {
+		updated, err := sender.IssueUpdated(e.IssueKey)
+		if err != nil {
+			return err
+		}
+		if updated.After(e.Updated) {
+			return &conflictError{updated}
+		}
+	}
+
+	switch e.Kind {
+	case Comment:
+		return sender.AddComment(e.IssueKey, e.Value)
+	case Transition:
+		return sender.TransitionIssue(e.IssueKey, e.Value)
+	case Assign:
+		return sender.AssignIssue(e.IssueKey, e.Value)
+	}
+	return errors.New("unknown change " + string(e.Kind))
+}
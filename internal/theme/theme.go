@@ -0,0 +1,309 @@
+// Package theme holds the colors of the TUI. A theme is one of the built-in
+// ones or a YAML file, either in the themes directory of the config or at any
+// path, starting from a built-in theme and changing some of its colors:
+//
+//	base: light
+//	glamour: ~/.config/jiratui/glamour.json
+//	colors:
+//	  focused: "#d75f00"
+//	  keyword: "5"
+//
+// The colors are ANSI numbers or hex codes, the empty ones use the color of the
+// terminal. NO_COLOR is respected: the colors are dropped and the focus is shown
+// by the shape of the borders.
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+
+	"github.com/SpanishInquisition49/JiraTUI/internal/xdg"
+)
+
+// The names of the built-in themes, Auto picks dark or light from the background of the terminal
+const (
+	Auto         = "auto"
+	Dark         = "dark"
+	Light        = "light"
+	HighContrast = "high-contrast"
+	Solarized    = "solarized"
+)
+
+// Names lists the built-in themes
+var Names = []string{Dark, Light, HighContrast, Solarized}
+
+// The shapes of the borders of the panes
+const (
+	BorderRounded = "rounded"
+	BorderNormal  = "normal"
+	BorderThick   = "thick"
+	BorderDouble  = "double"
+)
+
+// The glamour style of the Markdown without colors
+const glamourNoColor = "notty"
+
+// Palette holds the colors of the TUI, by role
+type Palette struct {
+	Border            string `yaml:"border"`             // The borders of the panes
+	Focused           string `yaml:"focused"`            // The border of the focused pane
+	Title             string `yaml:"title"`              // The titles of the lists and of the panes
+	Status            string `yaml:"status"`             // The status under the lists
+	Summary           string `yaml:"summary"`            // The summary of the issue on the card
+	Label             string `yaml:"label"`              // The labels of the fields on the card
+	Muted             string `yaml:"muted"`              // The values on the card, the inactive tabs
+	Selected          string `yaml:"selected"`           // The highlighted entry of the lists
+	New               string `yaml:"new"`                // The issues new since the last refresh
+	Updated           string `yaml:"updated"`            // The issues updated since the last refresh
+	Dropped           string `yaml:"dropped"`            // The issues no longer in the results
+	Field             string `yaml:"field"`              // The fields of the JQL queries
+	Keyword           string `yaml:"keyword"`            // The keywords of the JQL queries
+	Operator          string `yaml:"operator"`           // The operators of the JQL queries
+	String            string `yaml:"string"`             // The strings of the JQL queries
+	Function          string `yaml:"function"`           // The functions of the JQL queries
+	Error             string `yaml:"error"`              // The errors
+	ProfileText       string `yaml:"profile_text"`       // The name of the profile in the header
+	ProfileBackground string `yaml:"profile_background"` // The background of the name of the profile
+}
+
+// Theme is the look of the TUI
+type Theme struct {
+	Base          string  `yaml:"base,omitempty"`           // The built-in theme a file starts from, detected if empty
+	Glamour       string  `yaml:"glamour,omitempty"`        // The glamour style of the Markdown, a standard name or the path of a JSON style
+	Border        string  `yaml:"border,omitempty"`         // The shape of the borders
+	FocusedBorder string  `yaml:"focused_border,omitempty"` // The shape of the border of the focused pane
+	Colors        Palette `yaml:"colors"`
+}
+
+// builtins are the themes shipped with the application
+var builtins = map[string]Theme{
+	Dark: {
+		Glamour:       "dark",
+		Border:        BorderRounded,
+		FocusedBorder: BorderRounded,
+		Colors: Palette{
+			Border: "12", Focused: "11", Title: "11", Status: "9", Summary: "9",
+			Label: "6", Muted: "8", Selected: "10",
+			New: "10", Updated: "11", Dropped: "8",
+			Field: "6", Keyword: "5", Operator: "11", String: "10", Function: "12",
+			Error: "9", ProfileText: "0", ProfileBackground: "12",
+		},
+	},
+	// The bright colors of the dark theme are hard to read on a white background
+	Light: {
+		Glamour:       "light",
+		Border:        BorderRounded,
+		FocusedBorder: BorderRounded,
+		Colors: Palette{
+			Border: "4", Focused: "5", Title: "5", Status: "1", Summary: "1",
+			Label: "6", Muted: "8", Selected: "2",
+			New: "2", Updated: "3", Dropped: "8",
+			Field: "6", Keyword: "5", Operator: "3", String: "2", Function: "4",
+			Error: "1", ProfileText: "15", ProfileBackground: "4",
+		},
+	},
+	HighContrast: {
+		Glamour:       "dark",
+		Border:        BorderNormal,
+		FocusedBorder: BorderThick,
+		Colors: Palette{
+			Border: "15", Focused: "11", Title: "15", Status: "9", Summary: "15",
+			Label: "14", Muted: "7", Selected: "11",
+			New: "10", Updated: "11", Dropped: "7",
+			Field: "14", Keyword: "13", Operator: "11", String: "10", Function: "12",
+			Error: "9", ProfileText: "0", ProfileBackground: "15",
+		},
+	},
+	// The accents of Solarized read on both of its backgrounds, glamour follows the terminal
+	Solarized: {
+		Glamour:       "auto",
+		Border:        BorderRounded,
+		FocusedBorder: BorderRounded,
+		Colors: Palette{
+			Border: "#268bd2", Focused: "#b58900", Title: "#b58900", Status: "#dc322f", Summary: "#cb4b16",
+			Label: "#2aa198", Muted: "#586e75", Selected: "#859900",
+			New: "#859900", Updated: "#b58900", Dropped: "#586e75",
+			Field: "#2aa198", Keyword: "#d33682", Operator: "#b58900", String: "#859900", Function: "#268bd2",
+			Error: "#dc322f", ProfileText: "#fdf6e3", ProfileBackground: "#268bd2",
+		},
+	},
+}
+
+/**
+ * Default returns the dark theme, used before the theme of the config is known
+ * @return Theme - The dark theme
+ */
+func Default() Theme {
+	return builtins[Dark]
+}
+
+/**
+ * Load returns a built-in theme or reads a theme file, without colors when NO_COLOR is set.
+ * It must be called before the TUI starts, the background of the terminal may be detected.
+ * @param spec string - auto or empty to detect dark or light, the name of a built-in
+ * theme, the name of a file of the themes directory without .yaml, or the path of a theme file
+ * @return Theme - The theme
+ * @return error - An error if the theme does not exist or is invalid
+ */
+func Load(spec string) (Theme, error) {
+	t, err := load(spec)
+	if err != nil {
+		return t, err
+	}
+	// glamour would query the background of the terminal at every render,
+	// while the TUI reads its input: the background is detected once, now
+	if t.Glamour == styles.AutoStyle {
+		t.Glamour = builtins[detect()].Glamour
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		t.Colors = Palette{}
+		t.Glamour = glamourNoColor
+		t.Border, t.FocusedBorder = BorderNormal, BorderThick
+	}
+	return t, nil
+}
+
+/**
+ * Validate checks a theme without detecting the background of the terminal
+ * @param spec string - The theme, as given to Load
+ * @return error - An error if the theme does not exist or is invalid
+ */
+func Validate(spec string) error {
+	if spec == "" || spec == Auto {
+		return nil
+	}
+	_, err := load(spec)
+	return err
+}
+
+// load returns the theme with its colors
+func load(spec string) (Theme, error) {
+	if spec == "" || spec == Auto {
+		return builtins[detect()], nil
+	}
+	if t, ok := builtins[spec]; ok {
+		return t, nil
+	}
+	path := spec
+	if !strings.ContainsRune(spec, filepath.Separator) && filepath.Ext(spec) == "" {
+		path = filepath.Join(Dir(), spec+".yaml")
+	}
+	content, err := os.ReadFile(xdg.ExpandHome(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return Theme{}, fmt.Errorf("unknown theme %q, use %s or a theme file", spec, strings.Join(append([]string{Auto}, Names...), ", "))
+	} else if err != nil {
+		return Theme{}, err
+	}
+
+	// The file changes the colors of its base
+	var file struct {
+		Base string `yaml:"base"`
+	}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return Theme{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	base := file.Base
+	if base == "" || base == Auto {
+		base = detect()
+	}
+	t, ok := builtins[base]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %s: unknown base %q, use %s", path, file.Base, strings.Join(Names, ", "))
+	}
+	if err := yaml.Unmarshal(content, &t); err != nil {
+		return Theme{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	t.Glamour = xdg.ExpandHome(t.Glamour)
+	if err := t.validate(); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	return t, nil
+}
+
+/**
+ * Dir returns the directory of the theme files named in the config
+ * @return string - The path of the directory, it may not exist
+ */
+func Dir() string {
+	return filepath.Join(xdg.ConfigHome(), "themes")
+}
+
+// detect picks the built-in theme matching the background of the terminal
+func detect() string {
+	if lipgloss.HasDarkBackground() {
+		return Dark
+	}
+	return Light
+}
+
+// hexColor matches the colors given as #rgb or #rrggbb
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validate checks the borders and the colors of a theme file
+func (t Theme) validate() error {
+	borders := []string{BorderRounded, BorderNormal, BorderThick, BorderDouble}
+	for name, border := range map[string]string{"border": t.Border, "focused_border": t.FocusedBorder} {
+		if !slices.Contains(borders, border) {
+			return fmt.Errorf("%s: unknown shape %q, use %s", name, border, strings.Join(borders, ", "))
+		}
+	}
+	if _, ok := styles.DefaultStyles[t.Glamour]; !ok && t.Glamour != styles.AutoStyle {
+		if _, err := os.Stat(t.Glamour); err != nil {
+			return fmt.Errorf("glamour: %q is neither a standard style nor a style file", t.Glamour)
+		}
+	}
+	c := t.Colors
+	// In order, for stable errors
+	colors := []struct{ name, color string }{
+		{"border", c.Border}, {"focused", c.Focused}, {"title", c.Title}, {"status", c.Status},
+		{"summary", c.Summary}, {"label", c.Label}, {"muted", c.Muted}, {"selected", c.Selected},
+		{"new", c.New}, {"updated", c.Updated}, {"dropped", c.Dropped},
+		{"field", c.Field}, {"keyword", c.Keyword}, {"operator", c.Operator}, {"string", c.String},
+		{"function", c.Function}, {"error", c.Error},
+		{"profile_text", c.ProfileText}, {"profile_background", c.ProfileBackground},
+	}
+	for _, c := range colors {
+		if err := validateColor(c.color); err != nil {
+			return fmt.Errorf("colors: %s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// validateColor accepts the ANSI numbers, the hex codes and the empty color of the terminal
+func validateColor(color string) error {
+	if color == "" || hexColor.MatchString(color) {
+		return nil
+	}
+	if n, err := strconv.Atoi(color); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	return fmt.Errorf("invalid color %q, use an ANSI number from 0 to 255 or #rrggbb", color)
+}
+
+/**
+ * BorderShape returns the lipgloss border of a shape
+ * @param shape string - One of the Border constants
+ * @return lipgloss.Border - The border, rounded for unknown shapes
+ */
+func BorderShape(shape string) lipgloss.Border {
+	switch shape {
+	case BorderNormal:
+		return lipgloss.NormalBorder()
+	case BorderThick:
+		return lipgloss.ThickBorder()
+	case BorderDouble:
+		return lipgloss.DoubleBorder()
+	}
+	return lipgloss.RoundedBorder()
+}
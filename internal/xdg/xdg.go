@@ -0,0 +1,70 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The name of the directory used by the application inside the XDG base directories
+const appName = "jiratui"
+
+/**
+ * StateHome returns the directory where the application keeps its state,
+ * such as the query history. $XDG_STATE_HOME is used if set, otherwise
+ * the default ~/.local/state is used.
+ * @return string - The path of the directory, it may not exist yet
+ */
+func StateHome() string {
+	return appDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+/**
+ * ConfigHome returns the directory where the application keeps its
+ * configuration. $XDG_CONFIG_HOME is used if set, otherwise the default
+ * ~/.config is used.
+ * @return string - The path of the directory, it may not exist yet
+ */
+func ConfigHome() string {
+	return appDir("XDG_CONFIG_HOME", ".config")
+}
+
+/**
+ * CacheHome returns the directory where the application keeps data that
+ * can be safely deleted. $XDG_CACHE_HOME is used if set, otherwise the
+ * default ~/.cache is used.
+ * @return string - The path of the directory, it may not exist yet
+ */
+func CacheHome() string {
+	return appDir("XDG_CACHE_HOME", ".cache")
+}
+
+func appDir(env string, fallback string) string {
+	base := os.Getenv(env)
+	// The specification requires relative paths to be ignored
+	if base == "" || !filepath.IsAbs(base) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		base = filepath.Join(home, fallback)
+	}
+	return filepath.Join(base, appName)
+}
+
+/**
+ * ExpandHome replaces the leading ~/ of a path with the home directory, as the shell would
+ * @param path string - The path, from the config or typed by the user
+ * @return string - The expanded path, unchanged if it does not start with ~/
+ */
+func ExpandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}